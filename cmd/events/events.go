@@ -0,0 +1,89 @@
+package events
+
+import (
+	"on-the-record-api/cmd/store"
+	"sync"
+)
+
+// Kinds of event.
+const (
+	// Notification events tell a user that they have a new notification.
+	Notification = "notification"
+	// NewPost events tell a user that someone whose posts appear on their
+	// timeline has published a review or list.
+	NewPost = "newPost"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind by
+// before it starts missing them.
+const subscriberBuffer = 16
+
+// Event is something that happened which connected clients may want to
+// hear about.
+type Event struct {
+	Kind string
+	// ActorID is the user whose action caused the event.
+	ActorID      string
+	Notification store.Notification
+}
+
+type subscriber struct {
+	events chan Event
+}
+
+// Bus passes events from the requests that cause them to the subscribers
+// of the users they are for. It only reaches subscribers in the same
+// process.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*subscriber]bool
+}
+
+// NewBus returns a bus without any subscribers.
+func NewBus() *Bus {
+	return &Bus{subscribers: map[string]map[*subscriber]bool{}}
+}
+
+// Subscribe returns the events for the given user, along with a function
+// that ends the subscription and closes the channel.
+func (b *Bus) Subscribe(userID string) (<-chan Event, func()) {
+	sub := &subscriber{events: make(chan Event, subscriberBuffer)}
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = map[*subscriber]bool{}
+	}
+	b.subscribers[userID][sub] = true
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers[userID], sub)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+			close(sub.events)
+		})
+	}
+}
+
+// Publish sends the event to the subscribers of each of the given users.
+// It never blocks: a subscriber whose buffer is full misses the event
+// rather than holding up the request that published it.
+func (b *Bus) Publish(event Event, userIDs ...string) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for sub := range b.subscribers[userID] {
+			select {
+			case sub.events <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
+)
+
+func (h *handler) blockUser(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	blockerID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var blockUserBody followUserParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&blockUserBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	if blockUserBody.ID == blockerID {
+		http.Error(w, "Can't block yourself", http.StatusBadRequest)
+		return
+	}
+
+	err := h.store.Block(r.Context(), blockerID, blockUserBody.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to block user", "error", err)
+		http.Error(w, "Failed to block user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) unblockUser(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	ID := r.URL.Query().Get("id")
+	if ID == "" {
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
+		return
+	}
+
+	blockerID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.store.Unblock(r.Context(), blockerID, ID); err != nil {
+		slog.Error("failed to unblock user", "error", err)
+		http.Error(w, "Failed to unblock user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) muteUser(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	muterID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var muteUserBody followUserParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&muteUserBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	if muteUserBody.ID == muterID {
+		http.Error(w, "Can't mute yourself", http.StatusBadRequest)
+		return
+	}
+
+	err := h.store.Mute(r.Context(), muterID, muteUserBody.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to mute user", "error", err)
+		http.Error(w, "Failed to mute user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) unmuteUser(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	ID := r.URL.Query().Get("id")
+	if ID == "" {
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
+		return
+	}
+
+	muterID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.store.Unmute(r.Context(), muterID, ID); err != nil {
+		slog.Error("failed to unmute user", "error", err)
+		http.Error(w, "Failed to unmute user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultCommentLimit = 20
+
+type addReviewCommentParams struct {
+	ReviewID int    `json:"reviewId"`
+	ParentID int    `json:"parentId"`
+	Body     string `json:"body"`
+}
+
+type addListCommentParams struct {
+	ListID   string `json:"listId"`
+	ParentID int    `json:"parentId"`
+	Body     string `json:"body"`
+}
+
+// CommentsResponse is one page of the top-level comments on a review or
+// list. NextCursor is passed back as the cursor query param to fetch the
+// following page.
+type CommentsResponse struct {
+	Items      []store.CommentBag `json:"items"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+	HasMore    bool               `json:"hasMore"`
+}
+
+func (h *handler) addReviewComment(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var addCommentBody addReviewCommentParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&addCommentBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	if strings.TrimSpace(addCommentBody.Body) == "" {
+		http.Error(w, "Comment cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.store.Review(r.Context(), addCommentBody.ReviewID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get review", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+	reviewUserID := post.Author.ID
+
+	canView, err := h.canViewPost(r.Context(), userID, post)
+	if err != nil {
+		slog.Error("failed to get review", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+
+	// Neither side of a block can interact with the other's posts
+	blocked, err := h.store.IsBlocked(r.Context(), userID, reviewUserID)
+	if err != nil {
+		slog.Error("failed to check for blocks", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, "user cannot comment on this review", http.StatusForbidden)
+		return
+	}
+
+	comment, err := h.store.CreateReviewComment(r.Context(), addCommentBody.ReviewID, store.Comment{
+		UserID:    userID,
+		ParentID:  addCommentBody.ParentID,
+		Body:      addCommentBody.Body,
+		CreatedOn: time.Now().UTC(),
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, store.ErrInvalidParent) {
+		http.Error(w, "Can only reply to a top-level comment on the same review", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to add comment", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+
+	h.notify(r.Context(), store.Notification{
+		UserID:   reviewUserID,
+		ActorID:  userID,
+		Kind:     store.NotificationReviewComment,
+		ReviewID: addCommentBody.ReviewID,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (h *handler) deleteReviewComment(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
+		return
+	}
+
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	commentUserID, reviewUserID, err := h.store.ReviewCommentOwners(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get comment owners", "error", err)
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	// Reviewers can moderate the comments on their own reviews
+	if currentUserID != commentUserID && currentUserID != reviewUserID {
+		slog.Error(
+			"user does not have permission to delete this comment",
+			"requestingId", currentUserID,
+			"commentUserId", commentUserID,
+		)
+		http.Error(w, "user cannot delete someone else's comment", http.StatusForbidden)
+		return
+	}
+
+	if err := h.store.DeleteReviewComment(r.Context(), id); err != nil {
+		slog.Error("could not delete comment", "error", err)
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) getReviewComments(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
+		return
+	}
+
+	viewerID, _ := middleware.UserID(r.Context())
+
+	post, err := h.store.Review(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+
+	// Comments on a review the viewer can't see are hidden along with it
+	canView, err := h.canViewPost(r.Context(), viewerID, post)
+	if err != nil {
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+
+	page, err := parsePage(r, defaultCommentLimit)
+	if err != nil {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra comment to find out whether there is another page
+	limit := page.Limit
+	page.Limit++
+
+	comments, err := h.store.ReviewComments(r.Context(), id, page)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get comments", "error", err)
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildComments(comments, limit))
+}
+
+func (h *handler) addListComment(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var addCommentBody addListCommentParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&addCommentBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	if strings.TrimSpace(addCommentBody.Body) == "" {
+		http.Error(w, "Comment cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.store.List(r.Context(), addCommentBody.ListID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get list", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+	listUserID := post.Author.ID
+
+	canView, err := h.canViewPost(r.Context(), userID, post)
+	if err != nil {
+		slog.Error("failed to get list", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	// Neither side of a block can interact with the other's posts
+	blocked, err := h.store.IsBlocked(r.Context(), userID, listUserID)
+	if err != nil {
+		slog.Error("failed to check for blocks", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, "user cannot comment on this list", http.StatusForbidden)
+		return
+	}
+
+	comment, err := h.store.CreateListComment(r.Context(), addCommentBody.ListID, store.Comment{
+		UserID:    userID,
+		ParentID:  addCommentBody.ParentID,
+		Body:      addCommentBody.Body,
+		CreatedOn: time.Now().UTC(),
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, store.ErrInvalidParent) {
+		http.Error(w, "Can only reply to a top-level comment on the same list", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to add comment", "error", err)
+		http.Error(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+
+	h.notify(r.Context(), store.Notification{
+		UserID:  listUserID,
+		ActorID: userID,
+		Kind:    store.NotificationListComment,
+		ListID:  addCommentBody.ListID,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (h *handler) deleteListComment(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
+		return
+	}
+
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	commentUserID, listUserID, err := h.store.ListCommentOwners(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get comment owners", "error", err)
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	// List owners can moderate the comments on their own lists
+	if currentUserID != commentUserID && currentUserID != listUserID {
+		slog.Error(
+			"user does not have permission to delete this comment",
+			"requestingId", currentUserID,
+			"commentUserId", commentUserID,
+		)
+		http.Error(w, "user cannot delete someone else's comment", http.StatusForbidden)
+		return
+	}
+
+	if err := h.store.DeleteListComment(r.Context(), id); err != nil {
+		slog.Error("could not delete comment", "error", err)
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) getListComments(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
+		return
+	}
+
+	viewerID, _ := middleware.UserID(r.Context())
+
+	post, err := h.store.List(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+
+	// Comments on a list the viewer can't see are hidden along with it
+	canView, err := h.canViewPost(r.Context(), viewerID, post)
+	if err != nil {
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	page, err := parsePage(r, defaultCommentLimit)
+	if err != nil {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra comment to find out whether there is another page
+	limit := page.Limit
+	page.Limit++
+
+	comments, err := h.store.ListComments(r.Context(), id, page)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get comments", "error", err)
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildComments(comments, limit))
+}
+
+// buildComments turns comments into a page. comments may hold one more
+// comment than limit, which signals that there is another page.
+func buildComments(comments []store.CommentBag, limit int) CommentsResponse {
+	response := CommentsResponse{}
+	if len(comments) > limit {
+		comments = comments[:limit]
+		response.HasMore = true
+		response.NextCursor = store.CursorForComment(comments[len(comments)-1]).Encode()
+	}
+	response.Items = comments
+
+	return response
+}
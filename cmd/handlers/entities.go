@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
+	"strconv"
+)
+
+// entityPostLimit is how many reviews and lists an entity page shows.
+const entityPostLimit = 20
+
+// EntityResponse is an entity's details along with the reviews and lists
+// that feature it.
+type EntityResponse struct {
+	store.Entity
+	Reviews []TimelineResponse `json:"reviews"`
+	Lists   []TimelineResponse `json:"lists"`
+}
+
+func (h *handler) getEntity(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	ID := r.URL.Query().Get("id")
+	entityType, err := strconv.Atoi(r.URL.Query().Get("type"))
+	if ID == "" || err != nil {
+		http.Error(w, "Missing query params: id and type", http.StatusBadRequest)
+		return
+	}
+
+	viewerID, _ := middleware.UserID(r.Context())
+
+	entity, err := h.store.Entity(r.Context(), ID, entityType, viewerID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Entity not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get entity", "error", err)
+		http.Error(w, "Failed to get entity", http.StatusInternalServerError)
+		return
+	}
+
+	reviews, err := h.store.EntityReviews(r.Context(), ID, entityType, viewerID, entityPostLimit)
+	if err != nil {
+		slog.Error("could not get entity reviews", "error", err)
+		http.Error(w, "Failed to get entity", http.StatusInternalServerError)
+		return
+	}
+
+	lists, err := h.store.EntityLists(r.Context(), ID, entityType, viewerID, entityPostLimit)
+	if err != nil {
+		slog.Error("could not get entity lists", "error", err)
+		http.Error(w, "Failed to get entity", http.StatusInternalServerError)
+		return
+	}
+
+	// Enrich both together so that the like and comment counts are fetched
+	// once
+	items, err := h.enrichPosts(r.Context(), append(reviews, lists...), viewerID)
+	if err != nil {
+		slog.Error("could not get entity", "error", err)
+		http.Error(w, "Failed to get entity", http.StatusInternalServerError)
+		return
+	}
+
+	response := EntityResponse{
+		Entity:  entity,
+		Reviews: items[:len(reviews)],
+		Lists:   items[len(reviews):],
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
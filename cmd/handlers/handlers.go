@@ -2,74 +2,289 @@ package handlers
 
 import (
 	"net/http"
+	"on-the-record-api/cmd/events"
 	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
 
 	"github.com/gorilla/mux"
 )
 
-func RegisterHandlers() {
+type handler struct {
+	store  store.Store
+	events *events.Bus
+}
+
+// RegisterHandlers registers the API's routes on http.DefaultServeMux.
+func RegisterHandlers(s store.Store) {
+	http.Handle("/", NewRouter(s))
+}
+
+// NewRouter returns a router serving the API on top of the given store.
+func NewRouter(s store.Store) *mux.Router {
+	h := &handler{store: s, events: events.NewBus()}
+
 	r := mux.NewRouter()
-	r.HandleFunc("/user", getUser).Methods("GET", "OPTIONS")
-	r.HandleFunc("/user/featured", getFeaturedUsers).Methods("GET", "OPTIONS")
-	r.HandleFunc("/user/search", searchUser).Methods("GET", "OPTIONS")
-	r.HandleFunc("/user/activity", getActivity).Methods("GET", "OPTIONS")
 	r.HandleFunc(
 		"/user",
-		middleware.EnsureValidToken()(http.HandlerFunc(addUser)).ServeHTTP,
+		middleware.OptionalToken()(http.HandlerFunc(h.getUser)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc("/user/featured", h.getFeaturedUsers).Methods("GET", "OPTIONS")
+	r.HandleFunc("/user/search", h.searchUser).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/user/activity",
+		middleware.OptionalToken()(http.HandlerFunc(h.getActivity)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/user/followers",
+		middleware.OptionalToken()(http.HandlerFunc(h.getFollowers)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/user/following",
+		middleware.OptionalToken()(http.HandlerFunc(h.getFollowing)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/user",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:users")(http.HandlerFunc(h.addUser)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
 	r.HandleFunc(
 		"/user/follow",
-		middleware.EnsureValidToken()(http.HandlerFunc(followUser)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.followUser)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
 	r.HandleFunc(
 		"/user/unfollow",
-		middleware.EnsureValidToken()(http.HandlerFunc(unfollowUser)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.unfollowUser)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/user/drafts",
+		middleware.EnsureValidToken()(http.HandlerFunc(h.getDrafts)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/user/follow-requests",
+		middleware.EnsureValidToken()(http.HandlerFunc(h.getFollowRequests)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/user/follow-requests/approve",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.approveFollowRequest)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/user/follow-requests/deny",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.denyFollowRequest)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/user/block",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.blockUser)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/user/block",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.unblockUser)),
+		).ServeHTTP,
+	).Methods("DELETE", "OPTIONS")
+	r.HandleFunc(
+		"/user/mute",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.muteUser)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/user/mute",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:follows")(http.HandlerFunc(h.unmuteUser)),
+		).ServeHTTP,
+	).Methods("DELETE", "OPTIONS")
 	r.HandleFunc(
 		"/user",
-		middleware.EnsureValidToken()(http.HandlerFunc(updateUser)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:users")(http.HandlerFunc(h.updateUser)),
+		).ServeHTTP,
 	).Methods("PUT", "OPTIONS")
 	r.HandleFunc(
 		"/user",
-		middleware.EnsureValidToken()(http.HandlerFunc(deleteUser)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:users")(http.HandlerFunc(h.deleteUser)),
+		).ServeHTTP,
 	).Methods("DELETE", "OPTIONS")
 
-	r.HandleFunc("/review/likes", getReviewLikes).Methods("GET", "OPTIONS")
 	r.HandleFunc(
 		"/review",
-		middleware.EnsureValidToken()(http.HandlerFunc(addReview)).ServeHTTP,
+		middleware.OptionalToken()(http.HandlerFunc(h.getReview)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/review/likes",
+		middleware.OptionalToken()(http.HandlerFunc(h.getReviewLikes)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/review",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:reviews")(http.HandlerFunc(h.addReview)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/review",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:reviews")(http.HandlerFunc(h.updateReview)),
+		).ServeHTTP,
+	).Methods("PUT", "OPTIONS")
+	r.HandleFunc(
+		"/review/revisions",
+		middleware.EnsureValidToken()(http.HandlerFunc(h.getReviewRevisions)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
 	r.HandleFunc(
 		"/review/like",
-		middleware.EnsureValidToken()(http.HandlerFunc(likeReview)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:likes")(http.HandlerFunc(h.likeReview)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
 	r.HandleFunc(
 		"/review/unlike",
-		middleware.EnsureValidToken()(http.HandlerFunc(unlikeReview)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:likes")(http.HandlerFunc(h.unlikeReview)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
 	r.HandleFunc(
 		"/review",
-		middleware.EnsureValidToken()(http.HandlerFunc(deleteReview)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:reviews")(http.HandlerFunc(h.deleteReview)),
+		).ServeHTTP,
+	).Methods("DELETE", "OPTIONS")
+	r.HandleFunc(
+		"/review/comments",
+		middleware.OptionalToken()(http.HandlerFunc(h.getReviewComments)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/review/comment",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:comments")(http.HandlerFunc(h.addReviewComment)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/review/comment",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:comments")(http.HandlerFunc(h.deleteReviewComment)),
+		).ServeHTTP,
 	).Methods("DELETE", "OPTIONS")
 
-	r.HandleFunc("/list/likes", getListLikes).Methods("GET", "OPTIONS")
 	r.HandleFunc(
 		"/list",
-		middleware.EnsureValidToken()(http.HandlerFunc(addList)).ServeHTTP,
+		middleware.OptionalToken()(http.HandlerFunc(h.getList)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/list/likes",
+		middleware.OptionalToken()(http.HandlerFunc(h.getListLikes)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/list",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:lists")(http.HandlerFunc(h.addList)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/list",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:lists")(http.HandlerFunc(h.updateList)),
+		).ServeHTTP,
+	).Methods("PUT", "OPTIONS")
+	r.HandleFunc(
+		"/list",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:lists")(http.HandlerFunc(h.updateListElements)),
+		).ServeHTTP,
+	).Methods("PATCH", "OPTIONS")
 	r.HandleFunc(
 		"/list/like",
-		middleware.EnsureValidToken()(http.HandlerFunc(likeList)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:likes")(http.HandlerFunc(h.likeList)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
 	r.HandleFunc(
 		"/list/unlike",
-		middleware.EnsureValidToken()(http.HandlerFunc(unlikeList)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:likes")(http.HandlerFunc(h.unlikeList)),
+		).ServeHTTP,
 	).Methods("POST", "OPTIONS")
 	r.HandleFunc(
 		"/list",
-		middleware.EnsureValidToken()(http.HandlerFunc(deleteList)).ServeHTTP,
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:lists")(http.HandlerFunc(h.deleteList)),
+		).ServeHTTP,
 	).Methods("DELETE", "OPTIONS")
+	r.HandleFunc(
+		"/list/fork",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:lists")(http.HandlerFunc(h.forkList)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/list/collaborators",
+		middleware.OptionalToken()(http.HandlerFunc(h.getListCollaborators)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/list/collaborator",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:lists")(http.HandlerFunc(h.addListCollaborator)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/list/collaborator",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:lists")(http.HandlerFunc(h.removeListCollaborator)),
+		).ServeHTTP,
+	).Methods("DELETE", "OPTIONS")
+	r.HandleFunc(
+		"/list/comments",
+		middleware.OptionalToken()(http.HandlerFunc(h.getListComments)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/list/comment",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:comments")(http.HandlerFunc(h.addListComment)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/list/comment",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:comments")(http.HandlerFunc(h.deleteListComment)),
+		).ServeHTTP,
+	).Methods("DELETE", "OPTIONS")
+
+	r.HandleFunc(
+		"/entity",
+		middleware.OptionalToken()(http.HandlerFunc(h.getEntity)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+
+	r.HandleFunc(
+		"/notifications",
+		middleware.EnsureValidToken()(http.HandlerFunc(h.getNotifications)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+	r.HandleFunc(
+		"/notifications/read",
+		middleware.EnsureValidToken()(
+			middleware.RequireScopes("write:notifications")(http.HandlerFunc(h.markNotificationsRead)),
+		).ServeHTTP,
+	).Methods("POST", "OPTIONS")
+	r.HandleFunc(
+		"/events",
+		middleware.EnsureValidToken()(http.HandlerFunc(h.streamEvents)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+
+	r.HandleFunc(
+		"/timeline",
+		middleware.EnsureValidToken()(http.HandlerFunc(h.getTimeline)).ServeHTTP,
+	).Methods("GET", "OPTIONS")
+
+	r.HandleFunc("/ready", h.getReadiness).Methods("GET", "OPTIONS")
 
-	r.HandleFunc("/timeline", getTimeline).Methods("GET", "OPTIONS")
-	http.Handle("/", r)
+	return r
 }
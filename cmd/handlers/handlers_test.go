@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
+	"on-the-record-api/cmd/store/memory"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/gorilla/mux"
+	jose "gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+// The tests sign their own tokens, which the routes are set up to accept
+// in place of ones from Auth0.
+const (
+	testSigningKey = "on-the-record-test-signing-key"
+	testIssuer     = "https://on-the-record.test/"
+	testAudience   = "on-the-record-test"
+)
+
+// allScopes are granted to every test token unless a test asks for fewer.
+var allScopes = []string{
+	"write:users", "write:follows", "write:reviews", "write:lists",
+	"write:likes", "write:comments", "write:notifications",
+}
+
+func TestMain(m *testing.M) {
+	v, err := validator.New(
+		func(context.Context) (interface{}, error) {
+			return []byte(testSigningKey), nil
+		},
+		validator.HS256,
+		testIssuer,
+		[]string{testAudience},
+		validator.WithCustomClaims(func() validator.CustomClaims {
+			return &middleware.CustomClaims{}
+		}),
+	)
+	if err != nil {
+		panic(err)
+	}
+	middleware.UseValidator(v)
+
+	// Requests that are meant to fail log errors, which would drown out the
+	// test output
+	log.SetOutput(io.Discard)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	os.Exit(m.Run())
+}
+
+// testServer is the API running on top of an in-memory store.
+type testServer struct {
+	t      *testing.T
+	store  *memory.Store
+	router *mux.Router
+	// imageURL serves an image that reviews can use as their cover.
+	imageURL string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+		for x := 0; x < 2; x++ {
+			for y := 0; y < 2; y++ {
+				img.Set(x, y, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+			}
+		}
+		jpeg.Encode(w, img, nil)
+	}))
+	t.Cleanup(images.Close)
+
+	s := memory.New()
+	return &testServer{t: t, store: s, router: NewRouter(s), imageURL: images.URL}
+}
+
+// userID returns the ID the API gives the Auth0 user with the given name.
+func userID(name string) string {
+	return "0" + name
+}
+
+// token returns a token for the Auth0 user with the given name, granted
+// the given scopes, or every scope if none are given.
+func (s *testServer) token(name string, scopes ...string) string {
+	s.t.Helper()
+
+	if len(scopes) == 0 {
+		scopes = allScopes
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.HS256, Key: []byte(testSigningKey)},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	now := time.Now()
+	token, err := jwt.Signed(signer).
+		Claims(jwt.Claims{
+			Issuer:   testIssuer,
+			Subject:  "auth0|" + name,
+			Audience: jwt.Audience{testAudience},
+			IssuedAt: jwt.NewNumericDate(now),
+			Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+		}).
+		Claims(map[string]interface{}{"scope": strings.Join(scopes, " ")}).
+		CompactSerialize()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	return token
+}
+
+// do sends a request as the named user, or anonymously if name is empty,
+// with body encoded as JSON if it isn't nil.
+func (s *testServer) do(method string, target string, name string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	token := ""
+	if name != "" {
+		token = s.token(name)
+	}
+
+	return s.doWithToken(method, target, token, body)
+}
+
+// doWithToken sends a request that carries the given token, if any.
+func (s *testServer) doWithToken(method string, target string, token string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var requestBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&requestBody).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	r := httptest.NewRequest(method, target, &requestBody)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+	return w
+}
+
+// mustDo is do for requests that are expected to get the given status.
+func (s *testServer) mustDo(status int, method string, target string, name string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	w := s.do(method, target, name, body)
+	if w.Code != status {
+		s.t.Fatalf("%s %s: expected status %d, got %d: %s", method, target, status, w.Code, w.Body.String())
+	}
+
+	return w
+}
+
+// addUser signs the named user up.
+func (s *testServer) addUser(name string, isPrivate bool) {
+	s.t.Helper()
+	s.mustDo(http.StatusCreated, "POST", "/user", name, addUserParams{Name: name, IsPrivate: isPrivate})
+}
+
+// addReview posts a review as the named user and returns its ID.
+func (s *testServer) addReview(name string, entityID string, visibility string) int {
+	s.t.Helper()
+
+	s.mustDo(http.StatusCreated, "POST", "/review", name, addReviewParams{
+		EntityID:    entityID,
+		Type:        1,
+		Title:       "Title of " + entityID,
+		ImageSource: s.imageURL,
+		Score:       7,
+		Body:        "A review of " + entityID,
+		Visibility:  visibility,
+	})
+
+	return s.latestPost(name).Review.ID
+}
+
+// addList posts a list as the named user and returns its ID.
+func (s *testServer) addList(name string, title string, visibility string) string {
+	s.t.Helper()
+
+	s.mustDo(http.StatusCreated, "POST", "/list", name, addListParams{
+		Type:         1,
+		Title:        title,
+		ListElements: []store.ListElement{{EntityID: "e1", Name: "First"}, {EntityID: "e2", Name: "Second"}},
+		Visibility:   visibility,
+	})
+
+	return s.latestPost(name).List.ID
+}
+
+// latestPost returns the named user's newest post, including drafts.
+func (s *testServer) latestPost(name string) store.Post {
+	s.t.Helper()
+
+	ctx := context.Background()
+	posts, err := s.store.Activity(ctx, userID(name), true, store.Page{Limit: 1})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	drafts, err := s.store.Drafts(ctx, userID(name), store.Page{Limit: 1})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	posts = append(posts, drafts...)
+	if len(posts) == 0 {
+		s.t.Fatalf("%s has not posted anything", name)
+	}
+
+	latest := posts[0]
+	for _, post := range posts[1:] {
+		if post.Timestamp.After(latest.Timestamp) {
+			latest = post
+		}
+	}
+
+	return latest
+}
+
+// decode decodes the JSON body of the response into v.
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.NewDecoder(w.Body).Decode(&v); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+
+	return v
+}
+
+// feedPage is a page of a feed as clients see it.
+type feedPage struct {
+	Items []struct {
+		Author   store.UserCondensed `json:"author"`
+		Type     int                 `json:"type"`
+		Data     json.RawMessage     `json:"data"`
+		NumLikes int                 `json:"numLikes"`
+		IsLiked  bool                `json:"isLiked"`
+	} `json:"items"`
+	NextCursor string `json:"nextCursor"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+// ids returns the ID of each post in the page.
+func (p feedPage) ids(t *testing.T) []string {
+	t.Helper()
+
+	ids := []string{}
+	for _, item := range p.Items {
+		var data struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(item.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, strings.Trim(string(data.ID), `"`))
+	}
+
+	return ids
+}
@@ -1,52 +1,109 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
 	"time"
-
-	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"unicode/utf8"
 )
 
-type ListElement struct {
-	EntityID string `json:"entityId"`
-	Name     string `json:"name"`
-	ImageSrc string `json:"src"`
+type addListParams struct {
+	Type         int                 `json:"type"`
+	Title        string              `json:"title"`
+	Colour       string              `json:"colour"`
+	Ranked       bool                `json:"ranked"`
+	ListElements []store.ListElement `json:"listElements"`
+	Visibility   string              `json:"visibility"`
 }
 
-type addListParams struct {
-	Type         int           `json:"type"`
-	Title        string        `json:"title"`
-	Colour       string        `json:"colour"`
-	ListElements []ListElement `json:"listElements"`
+type updateListParams struct {
+	Title      string `json:"title"`
+	Colour     string `json:"colour"`
+	Visibility string `json:"visibility,omitempty"`
 }
 
-type likeListParams struct {
+type updateListElementsParams struct {
+	Operations []store.ListOperation `json:"operations"`
+}
+
+type listCollaboratorParams struct {
 	ListID string `json:"listId"`
+	UserID string `json:"userId"`
 }
 
-type List struct {
-	UserID       string        `json:"userId"`
-	Type         int           `json:"type"`
-	Title        string        `json:"title"`
-	Colour       string        `json:"colour"`
-	ListElements []ListElement `json:"listElements"`
-	CreatedOn    time.Time     `json:"createdOn"`
+type forkListParams struct {
+	ListID string `json:"listId"`
+	Title  string `json:"title"`
+}
+
+type likeListParams struct {
+	ListID string `json:"listId"`
 }
 
-func addList(w http.ResponseWriter, r *http.Request) {
+func (h *handler) getList(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	userID, err := extractUserIDFromJWTPayload(token)
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
+		return
+	}
+
+	viewerID, _ := middleware.UserID(r.Context())
+
+	post, err := h.store.List(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get list", http.StatusInternalServerError)
+		return
+	}
+
+	// Lists the viewer isn't allowed to see don't exist as far as they
+	// know
+	canView, err := h.canViewPost(r.Context(), viewerID, post)
+	if err != nil {
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get list", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	items, err := h.enrichPosts(r.Context(), []store.Post{post}, viewerID)
+	if err != nil {
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get list", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items[0])
+}
+
+func (h *handler) addList(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
@@ -62,125 +119,184 @@ func addList(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	if len(addListBody.ListElements) != 5 {
-		slog.Error("list must have five elements")
-		http.Error(w, "List must have five elements", http.StatusBadRequest)
+	if len(addListBody.ListElements) < store.MinListElements || len(addListBody.ListElements) > store.MaxListElements {
+		slog.Error("list has the wrong number of elements", "count", len(addListBody.ListElements))
+		http.Error(
+			w,
+			fmt.Sprintf("List must have between %d and %d elements", store.MinListElements, store.MaxListElements),
+			http.StatusBadRequest,
+		)
 		return
 	}
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	if addListBody.Visibility == "" {
+		addListBody.Visibility = store.VisibilityPublic
+	}
+	if !store.ValidVisibility(addListBody.Visibility) {
+		http.Error(w, "Visibility must be public, followers or private", http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
 
-	ctx := context.Background()
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		slog.Error("could not begin transaction", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	for i := range addListBody.ListElements {
+		if utf8.RuneCountInString(addListBody.ListElements[i].Note) > store.MaxListElementNoteLength {
+			http.Error(
+				w,
+				fmt.Sprintf("List element notes can't be longer than %d characters", store.MaxListElementNoteLength),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		addListBody.ListElements[i].AddedBy = store.UserCondensed{ID: userID}
+	}
+
+	list := store.List{
+		UserID:       userID,
+		Type:         addListBody.Type,
+		Title:        addListBody.Title,
+		Colour:       addListBody.Colour,
+		Ranked:       addListBody.Ranked,
+		ListElements: addListBody.ListElements,
+		Visibility:   addListBody.Visibility,
+		CreatedOn:    time.Now().UTC(),
+	}
+
+	if _, err := h.store.CreateList(r.Context(), list); err != nil {
+		slog.Error("failed to add list", "error", err)
+		http.Error(w, "Failed to add list", http.StatusInternalServerError)
 		return
 	}
 
-	query := "INSERT INTO lists (id, user_id, type, title, colour, created_on) VALUES ($1, $2, $3, $4, $5, $6);"
+	h.publishNewPost(r.Context(), list.UserID, list.Visibility)
 
-	stmt, err := tx.Prepare(query)
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// forkList copies another list's elements into a new list owned by the
+// caller. The new list keeps the original's title unless one is given. It
+// is public only if the original is a public list from a public account;
+// any other fork starts as a draft, because the caller's audience isn't the
+// one the original was shared with.
+func (h *handler) forkList(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var forkListBody forkListParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&forkListBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	source, err := h.store.List(r.Context(), forkListBody.ListID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-		return
-	}
-	defer stmt.Close()
-
-	id := uuid.NewString()
-	createdOn := time.Now().UTC()
-	_, err = stmt.Exec(
-		id,
-		userID,
-		addListBody.Type,
-		addListBody.Title,
-		addListBody.Colour,
-		createdOn,
-	)
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to fork list", http.StatusInternalServerError)
+		return
+	}
+
+	canView, err := h.canViewPost(r.Context(), userID, source)
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add list", http.StatusInternalServerError)
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to fork list", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	sourceIsPrivate, err := h.store.IsPrivate(r.Context(), source.Author.ID)
+	if err != nil {
+		slog.Error("could not get list owner", "error", err)
+		http.Error(w, "Failed to fork list", http.StatusInternalServerError)
 		return
 	}
 
-	query = "INSERT INTO list_elements (list_id, user_id, entity_id, title, image_src, placement) VALUES ($1, $2, $3, $4, $5, 1), ($1, $2, $6, $7, $8, 2), ($1, $2, $9, $10, $11, 3), ($1, $2, $12, $13, $14, 4), ($1, $2, $15, $16, $17, 5);"
+	visibility := store.VisibilityPublic
+	if source.Visibility() != store.VisibilityPublic || sourceIsPrivate {
+		visibility = store.VisibilityPrivate
+	}
 
-	stmt, err = tx.Prepare(query)
+	sourceElements, err := h.store.ListElements(r.Context(), []string{source.List.ID})
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-		return
-	}
-
-	_, err = stmt.Exec(
-		id,
-		userID,
-		addListBody.ListElements[0].EntityID,
-		addListBody.ListElements[0].Name,
-		addListBody.ListElements[0].ImageSrc,
-		addListBody.ListElements[1].EntityID,
-		addListBody.ListElements[1].Name,
-		addListBody.ListElements[1].ImageSrc,
-		addListBody.ListElements[2].EntityID,
-		addListBody.ListElements[2].Name,
-		addListBody.ListElements[2].ImageSrc,
-		addListBody.ListElements[3].EntityID,
-		addListBody.ListElements[3].Name,
-		addListBody.ListElements[3].ImageSrc,
-		addListBody.ListElements[4].EntityID,
-		addListBody.ListElements[4].Name,
-		addListBody.ListElements[4].ImageSrc,
-	)
+		slog.Error("could not get list elements", "error", err)
+		http.Error(w, "Failed to fork list", http.StatusInternalServerError)
+		return
+	}
+
+	elements := []store.ListElement{}
+	for _, element := range sourceElements[source.List.ID] {
+		element.AddedBy = store.UserCondensed{ID: userID}
+		elements = append(elements, element)
+	}
+
+	title := forkListBody.Title
+	if title == "" {
+		title = source.List.Title
+	}
+
+	id, err := h.store.CreateList(r.Context(), store.List{
+		UserID:       userID,
+		Type:         source.List.Type,
+		Title:        title,
+		Colour:       source.List.Colour,
+		Ranked:       source.List.Ranked,
+		ListElements: elements,
+		ForkedFrom:   source.List.ID,
+		Visibility:   visibility,
+		CreatedOn:    time.Now().UTC(),
+	})
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add list elements", http.StatusInternalServerError)
+		slog.Error("failed to fork list", "error", err)
+		http.Error(w, "Failed to fork list", http.StatusInternalServerError)
 		return
 	}
 
-	err = tx.Commit()
+	h.publishNewPost(r.Context(), userID, visibility)
+
+	fork, err := h.store.List(r.Context(), id)
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to commit transaction", "error", err)
-		http.Error(w, "Failed to add lists", http.StatusInternalServerError)
+		slog.Error("could not get forked list", "error", err)
+		http.Error(w, "Failed to fork list", http.StatusInternalServerError)
 		return
 	}
 
-	resp := List{
-		UserID:       userID,
-		Title:        addListBody.Title,
-		Colour:       addListBody.Colour,
-		ListElements: addListBody.ListElements,
-		CreatedOn:    createdOn,
+	items, err := h.enrichPosts(r.Context(), []store.Post{fork}, userID)
+	if err != nil {
+		slog.Error("could not get forked list", "error", err)
+		http.Error(w, "Failed to fork list", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(items[0])
 }
 
-func deleteList(w http.ResponseWriter, r *http.Request) {
+func (h *handler) deleteList(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
 
 	id := r.URL.Query().Get("id")
 	if id == "" {
@@ -188,19 +304,19 @@ func deleteList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	currentUserID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	getListUserQuery := "SELECT user_id FROM lists WHERE id = $1"
-
-	var listUserID string
-	err = db.QueryRow(getListUserQuery, id).Scan(&listUserID)
+	listUserID, err := h.store.ListOwner(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
+		slog.Error("failed to get list owner", "error", err)
 		http.Error(w, "Failed to delete list", http.StatusInternalServerError)
 		return
 	}
@@ -214,62 +330,121 @@ func deleteList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := context.Background()
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		slog.Error("could not begin transaction", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	if err := h.store.DeleteList(r.Context(), id); err != nil {
+		slog.Error("could not delete list", "error", err)
+		http.Error(w, "Failed to delete list", http.StatusInternalServerError)
 		return
 	}
 
-	query := "DELETE FROM list_elements WHERE list_id = $1;"
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
 
-	_, err = tx.Exec(query, id)
-	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete list", "error", err)
-		http.Error(w, "Failed to delete list", http.StatusInternalServerError)
+func (h *handler) updateList(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
+		return
+	}
+
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var updateListBody updateListParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&updateListBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
 		return
 	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
 
-	query = "DELETE FROM lists WHERE id = $1;"
+	if updateListBody.Visibility != "" && !store.ValidVisibility(updateListBody.Visibility) {
+		http.Error(w, "Visibility must be public, followers or private", http.StatusBadRequest)
+		return
+	}
 
-	_, err = tx.Exec(query, id)
+	post, err := h.store.List(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete list", "error", err)
-		http.Error(w, "Failed to delete list", http.StatusInternalServerError)
+		slog.Error("failed to get list", "error", err)
+		http.Error(w, "Failed to update list", http.StatusInternalServerError)
+		return
+	}
+	listUserID := post.Author.ID
+	if currentUserID != listUserID {
+		slog.Error(
+			"user does not have permission to update this list",
+			"requestingId", currentUserID,
+			"listUserId", listUserID,
+		)
+		http.Error(w, "user cannot update someone else's list", http.StatusForbidden)
 		return
 	}
 
-	err = tx.Commit()
+	err = h.store.UpdateList(r.Context(), id, store.ListUpdate{
+		Title:      updateListBody.Title,
+		Colour:     updateListBody.Colour,
+		Visibility: updateListBody.Visibility,
+		UpdatedOn:  time.Now().UTC(),
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to commit transaction", "error", err)
-		http.Error(w, "Failed to add lists", http.StatusInternalServerError)
+		slog.Error("failed to update list", "error", err)
+		http.Error(w, "Failed to update list", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	// Publishing a draft is announced like posting a new list
+	if post.Visibility() == store.VisibilityPrivate && updateListBody.Visibility != "" {
+		h.publishNewPost(r.Context(), listUserID, updateListBody.Visibility)
+	}
+
+	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updateListBody)
 }
 
-func likeList(w http.ResponseWriter, r *http.Request) {
+// updateListElements applies a batch of insert, remove and move operations
+// to a list's elements. Either every operation is applied or none are.
+func (h *handler) updateListElements(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	userID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
 		return
 	}
 
-	var likeListBody likeListParams
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var updateElementsBody updateListElementsParams
 	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&likeListBody); err != nil {
+	if err := decoder.Decode(&updateElementsBody); err != nil {
 		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
 		return
 	}
@@ -279,46 +454,150 @@ func likeList(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	db, err := connectToDB()
+	post, err := h.store.List(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		slog.Error("failed to get list", "error", err)
+		http.Error(w, "Failed to update list", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
 
-	// Make sure user hasn't already liked this list
-	checkIfUserHasLikedQuery := "SELECT COUNT(*) FROM list_likes WHERE user_id = $1 AND list_id = $2"
+	// Collaborators can add and reorder elements, but only the owner can
+	// remove them or change their notes
+	if currentUserID != post.Author.ID {
+		isCollaborator, err := h.store.IsListCollaborator(r.Context(), id, currentUserID)
+		if err != nil {
+			slog.Error("failed to check list collaborators", "error", err)
+			http.Error(w, "Failed to update list", http.StatusInternalServerError)
+			return
+		}
+		if !isCollaborator {
+			slog.Error(
+				"user does not have permission to update this list",
+				"requestingId", currentUserID,
+				"listUserId", post.Author.ID,
+			)
+			http.Error(w, "user cannot update someone else's list", http.StatusForbidden)
+			return
+		}
 
-	var count int
-	err = db.QueryRow(checkIfUserHasLikedQuery, userID, likeListBody.ListID).Scan(&count)
+		// A collaborator who can no longer see the list, because the owner
+		// has hidden it from them, can't edit it either
+		canView, err := h.canViewPost(r.Context(), currentUserID, post)
+		if err != nil {
+			slog.Error("failed to check list visibility", "error", err)
+			http.Error(w, "Failed to update list", http.StatusInternalServerError)
+			return
+		}
+		if !canView {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+
+		for _, op := range updateElementsBody.Operations {
+			if op.Op == store.RemoveListElement || op.Op == store.NoteListElement {
+				http.Error(w, "only the list's owner can remove elements or change their notes", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	for i := range updateElementsBody.Operations {
+		updateElementsBody.Operations[i].Element.AddedBy = store.UserCondensed{ID: currentUserID}
+	}
+
+	elements, err := h.store.UpdateListElements(r.Context(), id, updateElementsBody.Operations, time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, store.ErrInvalidListEdit) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		slog.Error("failed to update list elements", "error", err)
+		http.Error(w, "Failed to update list", http.StatusInternalServerError)
 		return
 	}
-	if count > 0 {
-		slog.Error("this user already has already liked this list", "User ID", userID, "List ID", likeListBody.ListID)
-		http.Error(w, "this user already has already liked this list", http.StatusBadRequest)
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(elements)
+}
+
+func (h *handler) addListCollaborator(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
 		return
 	}
 
-	query := "INSERT INTO list_likes (list_id, user_id) VALUES ($1, $2)"
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
 
-	stmt, err := db.Prepare(query)
+	var collaboratorBody listCollaboratorParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&collaboratorBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	post, err := h.store.List(r.Context(), collaboratorBody.ListID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
+		slog.Error("failed to get list", "error", err)
+		http.Error(w, "Failed to add collaborator", http.StatusInternalServerError)
+		return
+	}
+	if currentUserID != post.Author.ID {
+		http.Error(w, "only the list's owner can add collaborators", http.StatusForbidden)
+		return
+	}
+	if collaboratorBody.UserID == post.Author.ID {
+		http.Error(w, "the list's owner cannot be a collaborator", http.StatusBadRequest)
 		return
 	}
 
-	_, err = stmt.Exec(
-		likeListBody.ListID,
-		userID,
-	)
+	exists, err := h.store.UserExists(r.Context(), collaboratorBody.UserID)
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to like list", http.StatusInternalServerError)
+		slog.Error("failed to check for existing user", "error", err)
+		http.Error(w, "Failed to add collaborator", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	// Collaborators have to be able to see the list they're editing
+	canView, err := h.canViewPost(r.Context(), collaboratorBody.UserID, post)
+	if err != nil {
+		slog.Error("failed to check list visibility", "error", err)
+		http.Error(w, "Failed to add collaborator", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "the user can't see this list, so can't collaborate on it", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.AddListCollaborator(r.Context(), collaboratorBody.ListID, collaboratorBody.UserID); err != nil {
+		slog.Error("failed to add collaborator", "error", err)
+		http.Error(w, "Failed to add collaborator", http.StatusInternalServerError)
 		return
 	}
 
@@ -326,16 +605,108 @@ func likeList(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 }
 
-func unlikeList(w http.ResponseWriter, r *http.Request) {
+func (h *handler) removeListCollaborator(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	userID, err := extractUserIDFromJWTPayload(token)
+	listID := r.URL.Query().Get("listId")
+	userID := r.URL.Query().Get("userId")
+	if listID == "" || userID == "" {
+		http.Error(w, "Missing query params: listId and userId", http.StatusBadRequest)
+		return
+	}
+
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	listUserID, err := h.store.ListOwner(r.Context(), listID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+		slog.Error("failed to get list owner", "error", err)
+		http.Error(w, "Failed to remove collaborator", http.StatusInternalServerError)
+		return
+	}
+
+	// Collaborators can leave a list themselves
+	if currentUserID != listUserID && currentUserID != userID {
+		http.Error(w, "only the list's owner can remove collaborators", http.StatusForbidden)
+		return
+	}
+
+	if err := h.store.RemoveListCollaborator(r.Context(), listID, userID); err != nil {
+		slog.Error("failed to remove collaborator", "error", err)
+		http.Error(w, "Failed to remove collaborator", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) getListCollaborators(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	ID := r.URL.Query().Get("id")
+	if ID == "" {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
+		return
+	}
+
+	viewerID, _ := middleware.UserID(r.Context())
+
+	post, err := h.store.List(r.Context(), ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get collaborators", http.StatusInternalServerError)
+		return
+	}
+
+	canView, err := h.canViewPost(r.Context(), viewerID, post)
+	if err != nil {
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get collaborators", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	collaborators, err := h.store.ListCollaborators(r.Context(), ID)
+	if err != nil {
+		slog.Error("could not get collaborators", "error", err)
+		http.Error(w, "Failed to get collaborators", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collaborators)
+}
+
+func (h *handler) likeList(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
@@ -351,54 +722,118 @@ func unlikeList(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	db, err := connectToDB()
+	// Make sure user hasn't already liked this list
+	liked, err := h.store.HasLikedList(r.Context(), userID, likeListBody.ListID)
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		slog.Error("failed to check for existing like", "error", err)
+		http.Error(w, "Failed to like list", http.StatusInternalServerError)
+		return
+	}
+	if liked {
+		slog.Error("this user already has already liked this list", "User ID", userID, "List ID", likeListBody.ListID)
+		http.Error(w, "this user already has already liked this list", http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
 
-	// Make sure user hasn't already liked this list
-	checkIfUserHasLikedQuery := "SELECT COUNT(*) FROM list_likes WHERE user_id = $1 AND list_id = $2"
+	post, err := h.store.List(r.Context(), likeListBody.ListID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to like list", http.StatusInternalServerError)
+		return
+	}
 
-	var count int
-	err = db.QueryRow(checkIfUserHasLikedQuery, userID, likeListBody.ListID).Scan(&count)
+	canView, err := h.canViewPost(r.Context(), userID, post)
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to like list", http.StatusInternalServerError)
 		return
 	}
-	if count == 0 {
-		slog.Error("this user has not liked this list", "User ID", userID, "List ID", likeListBody.ListID)
-		http.Error(w, "this user already has not liked this list", http.StatusBadRequest)
+	if !canView {
+		http.Error(w, "List not found", http.StatusNotFound)
 		return
 	}
 
-	query := "DELETE FROM list_likes WHERE list_id = $1 AND user_id = $2;"
-
-	stmt, err := db.Prepare(query)
+	// Neither side of a block can interact with the other's posts
+	blocked, err := h.store.IsBlocked(r.Context(), userID, post.Author.ID)
 	if err != nil {
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
+		slog.Error("failed to check for blocks", "error", err)
+		http.Error(w, "Failed to like list", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, "user cannot like this list", http.StatusForbidden)
 		return
 	}
 
-	_, err = stmt.Exec(
-		likeListBody.ListID,
-		userID,
-	)
-	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
+	if err := h.store.LikeList(r.Context(), userID, likeListBody.ListID); err != nil {
+		slog.Error("failed to like list", "error", err)
 		http.Error(w, "Failed to like list", http.StatusInternalServerError)
 		return
 	}
 
+	h.notify(r.Context(), store.Notification{
+		UserID:  post.Author.ID,
+		ActorID: userID,
+		Kind:    store.NotificationListLike,
+		ListID:  likeListBody.ListID,
+	})
+
 	w.WriteHeader(http.StatusNoContent)
 	w.Header().Set("Content-Type", "application/json")
 }
 
-func getListLikes(w http.ResponseWriter, r *http.Request) {
+func (h *handler) unlikeList(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var likeListBody likeListParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&likeListBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	// Make sure user has liked this list
+	liked, err := h.store.HasLikedList(r.Context(), userID, likeListBody.ListID)
+	if err != nil {
+		slog.Error("failed to check for existing like", "error", err)
+		http.Error(w, "Failed to unlike list", http.StatusInternalServerError)
+		return
+	}
+	if !liked {
+		slog.Error("this user has not liked this list", "User ID", userID, "List ID", likeListBody.ListID)
+		http.Error(w, "this user already has not liked this list", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.UnlikeList(r.Context(), userID, likeListBody.ListID); err != nil {
+		slog.Error("failed to unlike list", "error", err)
+		http.Error(w, "Failed to unlike list", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) getListLikes(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
@@ -409,33 +844,35 @@ func getListLikes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := connectToDB()
+	viewerID, _ := middleware.UserID(r.Context())
+
+	post, err := h.store.List(r.Context(), ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		slog.Error("could not get list", "error", err)
+		http.Error(w, "Failed to get likes", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
-
-	query := "SELECT u.id, u.name, u.image_src FROM list_likes l JOIN users u ON l.user_id = u.id WHERE l.list_id = $1"
 
-	likeRows, err := db.Query(query, ID)
+	canView, err := h.canViewPost(r.Context(), viewerID, post)
 	if err != nil {
-		slog.Error("could not get likes", "error", err)
+		slog.Error("could not get list", "error", err)
 		http.Error(w, "Failed to get likes", http.StatusInternalServerError)
 		return
 	}
-	defer likeRows.Close()
+	if !canView {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
 
-	usersThatLiked := []UserCondensed{}
-	for likeRows.Next() {
-		var user UserCondensed
-		if err := likeRows.Scan(&user.ID, &user.Name, &user.ImageSource); err != nil {
-			slog.Error("failed to scan row", "error", err)
-			http.Error(w, "Could not get likes", http.StatusInternalServerError)
-			return
-		}
-		usersThatLiked = append(usersThatLiked, user)
+	usersThatLiked, err := h.store.ListLikes(r.Context(), ID)
+	if err != nil {
+		slog.Error("could not get likes", "error", err)
+		http.Error(w, "Failed to get likes", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"on-the-record-api/cmd/store"
+	"testing"
+)
+
+func TestListLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+
+	id := s.addList("alice", "Favourites", "")
+	target := "/list?id=" + id
+
+	list := decode[struct {
+		Author struct {
+			ID string `json:"id"`
+		} `json:"author"`
+		Data struct {
+			Title        string `json:"title"`
+			ListElements []struct {
+				EntityID string `json:"entityId"`
+			} `json:"listElements"`
+		} `json:"data"`
+	}](t, s.mustDo(http.StatusOK, "GET", target, "", nil))
+	if list.Author.ID != userID("alice") {
+		t.Errorf("expected the list to be by %s, got %s", userID("alice"), list.Author.ID)
+	}
+	if list.Data.Title != "Favourites" {
+		t.Errorf("unexpected title %q", list.Data.Title)
+	}
+	if len(list.Data.ListElements) != 2 || list.Data.ListElements[0].EntityID != "e1" || list.Data.ListElements[1].EntityID != "e2" {
+		t.Errorf("expected the elements to be e1 then e2, got %+v", list.Data.ListElements)
+	}
+
+	s.mustDo(http.StatusForbidden, "DELETE", target, "bob", nil)
+	s.mustDo(http.StatusNoContent, "DELETE", target, "alice", nil)
+	s.mustDo(http.StatusNotFound, "GET", target, "", nil)
+}
+
+func TestAddListValidatesElements(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+
+	s.mustDo(http.StatusBadRequest, "POST", "/list", "alice", addListParams{Type: 1, Title: "Empty"})
+}
+
+func TestLikeList(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+
+	id := s.addList("alice", "Favourites", "")
+	target := "/list?id=" + id
+
+	type likes struct {
+		NumLikes int  `json:"numLikes"`
+		IsLiked  bool `json:"isLiked"`
+	}
+
+	s.mustDo(http.StatusNoContent, "POST", "/list/like", "bob", likeListParams{ListID: id})
+
+	got := decode[likes](t, s.mustDo(http.StatusOK, "GET", target, "bob", nil))
+	if got != (likes{NumLikes: 1, IsLiked: true}) {
+		t.Errorf("after liking, expected 1 like by the viewer, got %+v", got)
+	}
+
+	s.mustDo(http.StatusNoContent, "POST", "/list/unlike", "bob", likeListParams{ListID: id})
+
+	got = decode[likes](t, s.mustDo(http.StatusOK, "GET", target, "bob", nil))
+	if got != (likes{}) {
+		t.Errorf("after unliking, expected no likes, got %+v", got)
+	}
+
+	s.mustDo(http.StatusNotFound, "POST", "/list/like", "bob", likeListParams{ListID: "missing"})
+}
+
+func TestCollaboratorsMustSeeTheList(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+
+	id := s.addList("alice", "Favourites", "followers")
+	target := "/list?id=" + id
+	invite := listCollaboratorParams{ListID: id, UserID: userID("bob")}
+	edit := updateListElementsParams{Operations: []store.ListOperation{
+		{Op: store.InsertListElement, Placement: 1, Element: store.ListElement{EntityID: "e3", Name: "Third"}},
+	}}
+
+	s.mustDo(http.StatusBadRequest, "POST", "/list/collaborator", "alice", invite)
+
+	s.mustDo(http.StatusNoContent, "POST", "/user/follow", "bob", followUserParams{ID: userID("alice")})
+	s.mustDo(http.StatusNoContent, "POST", "/list/collaborator", "alice", invite)
+	s.mustDo(http.StatusOK, "PATCH", target, "bob", edit)
+	s.mustDo(http.StatusOK, "GET", target, "bob", nil)
+
+	s.mustDo(http.StatusOK, "PUT", target, "alice", updateListParams{Title: "Favourites", Visibility: "private"})
+	s.mustDo(http.StatusNotFound, "GET", target, "bob", nil)
+	s.mustDo(http.StatusNotFound, "PATCH", target, "bob", edit)
+}
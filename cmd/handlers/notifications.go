@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"on-the-record-api/cmd/events"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
+	"time"
+)
+
+const defaultNotificationLimit = 20
+
+type markNotificationsReadParams struct {
+	IDs []int `json:"ids"`
+}
+
+// NotificationsResponse is one page of the user's notifications, along with
+// how many of all their notifications are unread.
+type NotificationsResponse struct {
+	Items       []store.NotificationBag `json:"items"`
+	NextCursor  string                  `json:"nextCursor,omitempty"`
+	HasMore     bool                    `json:"hasMore"`
+	UnreadCount int                     `json:"unreadCount"`
+}
+
+// notify tells the recipient about something the actor did. Users aren't
+// told about their own actions. Failing to notify doesn't undo the action,
+// so errors are only logged.
+func (h *handler) notify(ctx context.Context, notification store.Notification) {
+	if notification.UserID == notification.ActorID {
+		return
+	}
+
+	notification.CreatedOn = time.Now().UTC()
+	if err := h.store.Notify(ctx, notification); err != nil {
+		slog.Error("failed to add notification", "error", err, "kind", notification.Kind)
+		return
+	}
+
+	h.events.Publish(events.Event{
+		Kind:         events.Notification,
+		ActorID:      notification.ActorID,
+		Notification: notification,
+	}, notification.UserID)
+}
+
+func (h *handler) getNotifications(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	ID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePage(r, defaultNotificationLimit)
+	if err != nil {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra notification to find out whether there is another page
+	limit := page.Limit
+	page.Limit++
+
+	notifications, err := h.store.Notifications(r.Context(), ID, page)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get notifications", "error", err)
+		http.Error(w, "Failed to get notifications", http.StatusInternalServerError)
+		return
+	}
+
+	response := NotificationsResponse{}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		response.HasMore = true
+		response.NextCursor = store.CursorForNotification(notifications[len(notifications)-1]).Encode()
+	}
+	response.Items = notifications
+
+	response.UnreadCount, err = h.store.UnreadNotificationCount(r.Context(), ID)
+	if err != nil {
+		slog.Error("could not get unread notification count", "error", err)
+		http.Error(w, "Failed to get notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// markNotificationsRead marks the notifications with the given IDs as read,
+// or all of the caller's notifications if none are given.
+func (h *handler) markNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var markReadBody markNotificationsReadParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&markReadBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	if err := h.store.MarkNotificationsRead(r.Context(), userID, markReadBody.IDs); err != nil {
+		slog.Error("failed to mark notifications read", "error", err)
+		http.Error(w, "Failed to mark notifications read", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
 	"on-the-record-api/cmd/util"
+	"strconv"
 	"time"
-
-	_ "github.com/lib/pq"
 )
 
 type addReviewParams struct {
@@ -18,35 +20,80 @@ type addReviewParams struct {
 	ImageSource string `json:"imageSrc"`
 	Score       int    `json:"score"`
 	Body        string `json:"body"`
+	Visibility  string `json:"visibility"`
+}
+
+type updateReviewParams struct {
+	Title      string `json:"title"`
+	Subtitle   string `json:"subtitle"`
+	Score      int    `json:"score"`
+	Body       string `json:"body"`
+	Visibility string `json:"visibility,omitempty"`
 }
 
 type likeReviewParams struct {
 	ReviewID int `json:"reviewId"`
 }
 
-type Review struct {
-	UserID      string    `json:"userId"`
-	EntityID    string    `json:"entityId"`
-	Type        int       `json:"type"`
-	Title       string    `json:"title"`
-	Subtitle    string    `json:"subtitle"`
-	Colour      string    `json:"colour"`
-	ImageSource string    `json:"imageSrc"`
-	Score       int       `json:"score"`
-	Body        string    `json:"body"`
-	CreatedOn   time.Time `json:"createdOn"`
+func (h *handler) getReview(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
+		return
+	}
+
+	viewerID, _ := middleware.UserID(r.Context())
+
+	post, err := h.store.Review(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to get review", http.StatusInternalServerError)
+		return
+	}
+
+	// Reviews the viewer isn't allowed to see don't exist as far as they
+	// know
+	canView, err := h.canViewPost(r.Context(), viewerID, post)
+	if err != nil {
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to get review", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+
+	items, err := h.enrichPosts(r.Context(), []store.Post{post}, viewerID)
+	if err != nil {
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to get review", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items[0])
 }
 
-func addReview(w http.ResponseWriter, r *http.Request) {
+func (h *handler) addReview(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	userID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
@@ -62,99 +109,74 @@ func addReview(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	dominantColour, err := util.GetDominantColourFromImage(addReviewBody.ImageSource)
-	if err != nil {
-		slog.Error("could not get colour from image", "error", err)
-		http.Error(w, "Failed to get colour from image", http.StatusInternalServerError)
-		return
+	if addReviewBody.Visibility == "" {
+		addReviewBody.Visibility = store.VisibilityPublic
 	}
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	if !store.ValidVisibility(addReviewBody.Visibility) {
+		http.Error(w, "Visibility must be public, followers or private", http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
-
-	query := "INSERT INTO reviews (user_id, entity_id, type, title, subtitle, colour, image_src, score, body, created_on) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);"
 
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-		return
-	}
-	defer stmt.Close()
-
-	createdOn := time.Now().UTC()
-	_, err = stmt.Exec(
-		userID,
-		addReviewBody.EntityID,
-		addReviewBody.Type,
-		addReviewBody.Title,
-		addReviewBody.Subtitle,
-		dominantColour,
-		addReviewBody.ImageSource,
-		addReviewBody.Score,
-		addReviewBody.Body,
-		createdOn,
-	)
+	dominantColour, err := util.GetDominantColourFromImage(addReviewBody.ImageSource)
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add review", http.StatusInternalServerError)
+		slog.Error("could not get colour from image", "error", err)
+		http.Error(w, "Failed to get colour from image", http.StatusInternalServerError)
 		return
 	}
 
-	resp := Review{
+	review := store.Review{
 		UserID:      userID,
 		EntityID:    addReviewBody.EntityID,
+		Type:        addReviewBody.Type,
 		Title:       addReviewBody.Title,
 		Subtitle:    addReviewBody.Subtitle,
 		Colour:      dominantColour,
 		ImageSource: addReviewBody.ImageSource,
 		Score:       addReviewBody.Score,
 		Body:        addReviewBody.Body,
-		CreatedOn:   createdOn,
+		Visibility:  addReviewBody.Visibility,
+		CreatedOn:   time.Now().UTC(),
 	}
 
+	if err := h.store.CreateReview(r.Context(), review); err != nil {
+		slog.Error("failed to add review", "error", err)
+		http.Error(w, "Failed to add review", http.StatusInternalServerError)
+		return
+	}
+
+	h.publishNewPost(r.Context(), review.UserID, review.Visibility)
+
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(review)
 }
 
-func deleteReview(w http.ResponseWriter, r *http.Request) {
+func (h *handler) deleteReview(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
-	db, err := connectToDB()
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "Missing query params: id", http.StatusBadRequest)
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	currentUserID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	reviewUserID, err := h.store.ReviewOwner(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
 		return
 	}
-
-	getReviewUserQuery := "SELECT user_id FROM reviews WHERE id = $1"
-
-	var reviewUserID string
-	err = db.QueryRow(getReviewUserQuery, id).Scan(&reviewUserID)
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		slog.Error("failed to get review owner", "error", err)
+		http.Error(w, "Failed to delete review", http.StatusInternalServerError)
 		return
 	}
 	if currentUserID != reviewUserID {
@@ -167,10 +189,7 @@ func deleteReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := "DELETE FROM reviews WHERE id = $1;"
-
-	_, err = db.Exec(query, id)
-	if err != nil {
+	if err := h.store.DeleteReview(r.Context(), id); err != nil {
 		slog.Error("could not delete review", "error", err)
 		http.Error(w, "Failed to delete review", http.StatusInternalServerError)
 		return
@@ -180,22 +199,27 @@ func deleteReview(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 }
 
-func likeReview(w http.ResponseWriter, r *http.Request) {
+func (h *handler) updateReview(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	userID, err := extractUserIDFromJWTPayload(token)
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
 	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+		http.Error(w, "Missing query params: id", http.StatusBadRequest)
 		return
 	}
 
-	var likeReviewBody likeReviewParams
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	var updateReviewBody updateReviewParams
 	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&likeReviewBody); err != nil {
+	if err := decoder.Decode(&updateReviewBody); err != nil {
 		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
 		return
 	}
@@ -205,63 +229,115 @@ func likeReview(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	if updateReviewBody.Visibility != "" && !store.ValidVisibility(updateReviewBody.Visibility) {
+		http.Error(w, "Visibility must be public, followers or private", http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
 
-	// Make sure user hasn't already liked this review
-	checkIfUserHasLikedQuery := "SELECT COUNT(*) FROM review_likes WHERE user_id = $1 AND review_id = $2"
+	post, err := h.store.Review(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get review", "error", err)
+		http.Error(w, "Failed to update review", http.StatusInternalServerError)
+		return
+	}
+	reviewUserID := post.Author.ID
+	if currentUserID != reviewUserID {
+		slog.Error(
+			"user does not have permission to update this review",
+			"requestingId", currentUserID,
+			"reviewUserId", reviewUserID,
+		)
+		http.Error(w, "user cannot update someone else's review", http.StatusForbidden)
+		return
+	}
 
-	var count int
-	err = db.QueryRow(checkIfUserHasLikedQuery, userID, likeReviewBody.ReviewID).Scan(&count)
+	err = h.store.UpdateReview(r.Context(), id, store.ReviewEdit{
+		Title:      updateReviewBody.Title,
+		Subtitle:   updateReviewBody.Subtitle,
+		Score:      updateReviewBody.Score,
+		Body:       updateReviewBody.Body,
+		Visibility: updateReviewBody.Visibility,
+		EditedOn:   time.Now().UTC(),
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		slog.Error("failed to update review", "error", err)
+		http.Error(w, "Failed to update review", http.StatusInternalServerError)
 		return
 	}
-	if count > 0 {
-		slog.Error("this user already has already liked this review", "User ID", userID, "Review ID", likeReviewBody.ReviewID)
-		http.Error(w, "this user already has already liked this review", http.StatusBadRequest)
+
+	// Publishing a draft is announced like posting a new review
+	if post.Visibility() == store.VisibilityPrivate && updateReviewBody.Visibility != "" {
+		h.publishNewPost(r.Context(), reviewUserID, updateReviewBody.Visibility)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updateReviewBody)
+}
+
+func (h *handler) getReviewRevisions(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
 		return
 	}
 
-	query := "INSERT INTO review_likes (review_id, user_id) VALUES ($1, $2)"
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
 
-	stmt, err := db.Prepare(query)
+	// Only the author can see what their review used to say
+	reviewUserID, err := h.store.ReviewOwner(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
+		slog.Error("failed to get review owner", "error", err)
+		http.Error(w, "Failed to get revisions", http.StatusInternalServerError)
+		return
+	}
+	if currentUserID != reviewUserID {
+		http.Error(w, "user cannot see the revisions of someone else's review", http.StatusForbidden)
 		return
 	}
 
-	_, err = stmt.Exec(
-		likeReviewBody.ReviewID,
-		userID,
-	)
+	revisions, err := h.store.ReviewRevisions(r.Context(), id)
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to like review", http.StatusInternalServerError)
+		slog.Error("could not get revisions", "error", err)
+		http.Error(w, "Failed to get revisions", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
 }
 
-func unlikeReview(w http.ResponseWriter, r *http.Request) {
+func (h *handler) likeReview(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	userID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
@@ -277,91 +353,157 @@ func unlikeReview(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	db, err := connectToDB()
+	// Make sure user hasn't already liked this review
+	liked, err := h.store.HasLikedReview(r.Context(), userID, likeReviewBody.ReviewID)
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		slog.Error("failed to check for existing like", "error", err)
+		http.Error(w, "Failed to like review", http.StatusInternalServerError)
+		return
+	}
+	if liked {
+		slog.Error("this user already has already liked this review", "User ID", userID, "Review ID", likeReviewBody.ReviewID)
+		http.Error(w, "this user already has already liked this review", http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
 
-	// Make sure user hasn't already liked this review
-	checkIfUserHasLikedQuery := "SELECT COUNT(*) FROM review_likes WHERE user_id = $1 AND review_id = $2"
+	post, err := h.store.Review(r.Context(), likeReviewBody.ReviewID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to like review", http.StatusInternalServerError)
+		return
+	}
 
-	var count int
-	err = db.QueryRow(checkIfUserHasLikedQuery, userID, likeReviewBody.ReviewID).Scan(&count)
+	canView, err := h.canViewPost(r.Context(), userID, post)
 	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to like review", http.StatusInternalServerError)
 		return
 	}
-	if count == 0 {
-		slog.Error("this user has not liked this review", "User ID", userID, "Review ID", likeReviewBody.ReviewID)
-		http.Error(w, "this user already has not liked this review", http.StatusBadRequest)
+	if !canView {
+		http.Error(w, "Review not found", http.StatusNotFound)
 		return
 	}
 
-	query := "DELETE FROM review_likes WHERE review_id = $1 AND user_id = $2;"
-
-	stmt, err := db.Prepare(query)
+	// Neither side of a block can interact with the other's posts
+	blocked, err := h.store.IsBlocked(r.Context(), userID, post.Author.ID)
 	if err != nil {
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
+		slog.Error("failed to check for blocks", "error", err)
+		http.Error(w, "Failed to like review", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, "user cannot like this review", http.StatusForbidden)
 		return
 	}
 
-	_, err = stmt.Exec(
-		likeReviewBody.ReviewID,
-		userID,
-	)
-	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
+	if err := h.store.LikeReview(r.Context(), userID, likeReviewBody.ReviewID); err != nil {
+		slog.Error("failed to like review", "error", err)
 		http.Error(w, "Failed to like review", http.StatusInternalServerError)
 		return
 	}
 
+	h.notify(r.Context(), store.Notification{
+		UserID:   post.Author.ID,
+		ActorID:  userID,
+		Kind:     store.NotificationReviewLike,
+		ReviewID: likeReviewBody.ReviewID,
+	})
+
 	w.WriteHeader(http.StatusNoContent)
 	w.Header().Set("Content-Type", "application/json")
 }
 
-func getReviewLikes(w http.ResponseWriter, r *http.Request) {
+func (h *handler) unlikeReview(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
-	ID := r.URL.Query().Get("id")
-	if ID == "" {
-		http.Error(w, "Missing query param: id", http.StatusBadRequest)
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	db, err := connectToDB()
+	var likeReviewBody likeReviewParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&likeReviewBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	// Make sure user has liked this review
+	liked, err := h.store.HasLikedReview(r.Context(), userID, likeReviewBody.ReviewID)
+	if err != nil {
+		slog.Error("failed to check for existing like", "error", err)
+		http.Error(w, "Failed to unlike review", http.StatusInternalServerError)
+		return
+	}
+	if !liked {
+		slog.Error("this user has not liked this review", "User ID", userID, "Review ID", likeReviewBody.ReviewID)
+		http.Error(w, "this user already has not liked this review", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.UnlikeReview(r.Context(), userID, likeReviewBody.ReviewID); err != nil {
+		slog.Error("failed to unlike review", "error", err)
+		http.Error(w, "Failed to unlike review", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+func (h *handler) getReviewLikes(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	ID, err := strconv.Atoi(r.URL.Query().Get("id"))
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
 		return
 	}
-	defer db.Close()
 
-	query := "SELECT u.id, u.name, u.image_src FROM review_likes l JOIN users u ON l.user_id = u.id WHERE l.review_id = $1"
+	viewerID, _ := middleware.UserID(r.Context())
 
-	likeRows, err := db.Query(query, ID)
+	post, err := h.store.Review(r.Context(), ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("could not get likes", "error", err)
+		slog.Error("could not get review", "error", err)
 		http.Error(w, "Failed to get likes", http.StatusInternalServerError)
 		return
 	}
-	defer likeRows.Close()
 
-	usersThatLiked := []UserCondensed{}
-	for likeRows.Next() {
-		var user UserCondensed
-		if err := likeRows.Scan(&user.ID, &user.Name, &user.ImageSource); err != nil {
-			slog.Error("failed to scan row", "error", err)
-			http.Error(w, "Could not get likes", http.StatusInternalServerError)
-			return
-		}
-		usersThatLiked = append(usersThatLiked, user)
+	canView, err := h.canViewPost(r.Context(), viewerID, post)
+	if err != nil {
+		slog.Error("could not get review", "error", err)
+		http.Error(w, "Failed to get likes", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+
+	usersThatLiked, err := h.store.ReviewLikes(r.Context(), ID)
+	if err != nil {
+		slog.Error("could not get likes", "error", err)
+		http.Error(w, "Failed to get likes", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
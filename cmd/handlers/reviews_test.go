@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestReviewLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+
+	id := s.addReview("alice", "album", "")
+	target := fmt.Sprintf("/review?id=%d", id)
+
+	w := s.mustDo(http.StatusOK, "GET", target, "", nil)
+	review := decode[struct {
+		Author struct {
+			ID string `json:"id"`
+		} `json:"author"`
+		Data struct {
+			Body       string `json:"body"`
+			Colour     string `json:"colour"`
+			Visibility string `json:"visibility"`
+		} `json:"data"`
+	}](t, w)
+	if review.Author.ID != userID("alice") {
+		t.Errorf("expected the review to be by %s, got %s", userID("alice"), review.Author.ID)
+	}
+	if review.Data.Body != "A review of album" {
+		t.Errorf("unexpected body %q", review.Data.Body)
+	}
+	if review.Data.Colour == "" {
+		t.Error("expected a colour to be taken from the cover image")
+	}
+	if review.Data.Visibility != "public" {
+		t.Errorf("expected reviews to be public by default, got %q", review.Data.Visibility)
+	}
+
+	s.mustDo(http.StatusForbidden, "DELETE", target, "bob", nil)
+	s.mustDo(http.StatusNoContent, "DELETE", target, "alice", nil)
+	s.mustDo(http.StatusNotFound, "GET", target, "", nil)
+}
+
+func TestReviewRequiresScope(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+
+	w := s.doWithToken("POST", "/review", s.token("alice", "write:lists"), addReviewParams{EntityID: "album", ImageSource: s.imageURL})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	s.mustDo(http.StatusUnauthorized, "POST", "/review", "", addReviewParams{EntityID: "album"})
+}
+
+func TestLikeReview(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+
+	id := s.addReview("alice", "album", "")
+	target := fmt.Sprintf("/review?id=%d", id)
+
+	type likes struct {
+		NumLikes int  `json:"numLikes"`
+		IsLiked  bool `json:"isLiked"`
+	}
+
+	s.mustDo(http.StatusNoContent, "POST", "/review/like", "bob", likeReviewParams{ReviewID: id})
+	s.mustDo(http.StatusBadRequest, "POST", "/review/like", "bob", likeReviewParams{ReviewID: id})
+
+	got := decode[likes](t, s.mustDo(http.StatusOK, "GET", target, "bob", nil))
+	if got != (likes{NumLikes: 1, IsLiked: true}) {
+		t.Errorf("after liking, expected 1 like by the viewer, got %+v", got)
+	}
+	got = decode[likes](t, s.mustDo(http.StatusOK, "GET", target, "alice", nil))
+	if got != (likes{NumLikes: 1, IsLiked: false}) {
+		t.Errorf("expected another viewer to see 1 like that isn't theirs, got %+v", got)
+	}
+
+	s.mustDo(http.StatusNoContent, "POST", "/review/unlike", "bob", likeReviewParams{ReviewID: id})
+
+	got = decode[likes](t, s.mustDo(http.StatusOK, "GET", target, "bob", nil))
+	if got != (likes{}) {
+		t.Errorf("after unliking, expected no likes, got %+v", got)
+	}
+
+	s.mustDo(http.StatusNotFound, "POST", "/review/like", "bob", likeReviewParams{ReviewID: id + 100})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"on-the-record-api/cmd/events"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
+	"time"
+)
+
+// streamKeepAlive is how often an idle event stream gets a comment, so
+// that proxies don't close the connection.
+const streamKeepAlive = 30 * time.Second
+
+// NotificationEvent is sent down the event stream when the user gets a new
+// notification. Clients fetch /notifications for the full details.
+type NotificationEvent struct {
+	Kind        string `json:"kind"`
+	ActorID     string `json:"actorId"`
+	ReviewID    int    `json:"reviewId,omitempty"`
+	ListID      string `json:"listId,omitempty"`
+	UnreadCount int    `json:"unreadCount"`
+}
+
+// NewPostsEvent is sent down the event stream when someone the user follows
+// publishes a post, as a sign that their timeline has new items.
+type NewPostsEvent struct {
+	AuthorID string `json:"authorId"`
+}
+
+// publishNewPost tells the users whose timelines show the author's posts
+// that they have posted, unless the post is only visible to its author.
+func (h *handler) publishNewPost(ctx context.Context, authorID string, visibility string) {
+	if visibility == store.VisibilityPrivate {
+		return
+	}
+
+	audience, err := h.store.TimelineAudience(ctx, authorID)
+	if err != nil {
+		slog.Error("could not get timeline audience", "error", err)
+		return
+	}
+
+	h.events.Publish(events.Event{Kind: events.NewPost, ActorID: authorID}, audience...)
+}
+
+// streamEvents holds the connection open and sends the user server-sent
+// events as they happen: "notification" for each new notification and
+// "newPosts" when someone they follow posts.
+func (h *handler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		slog.Error("response writer does not support flushing")
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	stream, unsubscribe := h.events.Subscribe(userID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+
+			name, data, send := h.streamedEvent(r.Context(), userID, event)
+			if !send {
+				continue
+			}
+			if err := writeEvent(w, name, data); err != nil {
+				slog.Error("failed to write event", "error", err, "kind", event.Kind)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// streamedEvent turns an event from the bus into the name and data of the
+// event to send to the user. send is false if it couldn't be built.
+func (h *handler) streamedEvent(ctx context.Context, userID string, event events.Event) (name string, data any, send bool) {
+	switch event.Kind {
+	case events.Notification:
+		unreadCount, err := h.store.UnreadNotificationCount(ctx, userID)
+		if err != nil {
+			slog.Error("could not get unread notification count", "error", err)
+			return "", nil, false
+		}
+
+		return "notification", NotificationEvent{
+			Kind:        event.Notification.Kind,
+			ActorID:     event.ActorID,
+			ReviewID:    event.Notification.ReviewID,
+			ListID:      event.Notification.ListID,
+			UnreadCount: unreadCount,
+		}, true
+	case events.NewPost:
+		return "newPosts", NewPostsEvent{AuthorID: event.ActorID}, true
+	}
+
+	return "", nil, false
+}
+
+func writeEvent(w http.ResponseWriter, name string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+	return err
+}
@@ -1,243 +1,224 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"log/slog"
 	"net/http"
-	"sort"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
 	"strconv"
 	"time"
 )
 
-const (
-	ReviewType int = iota
-	ListType
-)
-
-type ListBag struct {
-	ID           string        `json:"id"`
-	Type         int           `json:"type"`
-	Title        string        `json:"title"`
-	Colour       string        `json:"colour"`
-	ListElements []ListElement `json:"listElements"`
+type TimelineResponse struct {
+	Author      store.UserCondensed `json:"author"`
+	Type        int                 `json:"type"`
+	Timestamp   time.Time           `json:"timestamp"`
+	Data        interface{}         `json:"data"`
+	NumLikes    int                 `json:"numLikes"`
+	IsLiked     bool                `json:"isLiked"`
+	NumComments int                 `json:"numComments"`
 }
 
-type ReviewBag struct {
-	ID          int    `json:"id"`
-	EntityID    string `json:"entityId"`
-	Type        int    `json:"type"`
-	Title       string `json:"title"`
-	Subtitle    string `json:"subtitle"`
-	Colour      string `json:"colour"`
-	ImageSource string `json:"imageSrc"`
-	Score       int    `json:"score"`
-	Body        string `json:"body"`
+// FeedResponse is one page of a feed. NextCursor is passed back as the
+// cursor query param to fetch the following page.
+type FeedResponse struct {
+	Items      []TimelineResponse `json:"items"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+	HasMore    bool               `json:"hasMore"`
 }
 
-type TimelineResponse struct {
-	Author    UserCondensed `json:"author"`
-	Type      int           `json:"type"`
-	Timestamp time.Time     `json:"timestamp"`
-	Data      interface{}   `json:"data"`
-	NumLikes  int           `json:"numLikes"`
-	IsLiked   bool          `json:"isLiked"`
-}
+const (
+	defaultFeedLimit = 3
+	maxFeedLimit     = 50
+)
 
-func getTimeline(w http.ResponseWriter, r *http.Request) {
+func (h *handler) getTimeline(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
-	ID := r.URL.Query().Get("id")
-	if ID == "" {
-		http.Error(w, "Missing query param: id", http.StatusBadRequest)
+	ID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil || limit < 0 {
-		limit = 3
+	page, err := parsePage(r, defaultFeedLimit)
+	if err != nil {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
 	}
 
-	db, err := connectToDB()
+	// Fetch one extra post to find out whether there is another page
+	limit := page.Limit
+	page.Limit++
+
+	posts, err := h.store.Timeline(r.Context(), ID, page)
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		slog.Error("could not get timeline", "error", err)
+		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
 
-	getFollowedUserIDsQuery := "SELECT followee_id FROM follower_relation WHERE follower_id = $1;"
-	followedUserRows, err := db.Query(getFollowedUserIDsQuery, ID)
+	response, err := h.buildFeed(r.Context(), posts, ID, limit)
 	if err != nil {
-		slog.Error("could not get followed users", "error", err)
+		slog.Error("could not get timeline", "error", err)
 		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
 		return
 	}
-	defer followedUserRows.Close()
 
-	followedUsers := []string{}
-	for followedUserRows.Next() {
-		var userID string
-		if err := followedUserRows.Scan(&userID); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-		followedUsers = append(followedUsers, userID)
+// getDrafts serves a page of the caller's unpublished reviews and lists.
+func (h *handler) getDrafts(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
 	}
-
-	whereClause := "user_id = $1"
-	for _, followedUser := range followedUsers {
-		whereClause = fmt.Sprintf("%s OR user_id = '%s'", whereClause, followedUser)
+	ID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
 	}
 
-	reviewQuery := fmt.Sprintf("SELECT r.id, r.entity_id, r.type, r.colour, r.image_src, r.title, r.subtitle, r.score, r.body, r.created_on, u.id, u.name, u.image_src FROM reviews r JOIN users u ON u.id = r.user_id WHERE %s ORDER BY r.created_on DESC;", whereClause)
-
-	reviewRows, err := db.Query(reviewQuery, ID)
+	page, err := parsePage(r, defaultFeedLimit)
 	if err != nil {
-		slog.Error("could not get timeline", "error", err)
-		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
 		return
 	}
-	defer reviewRows.Close()
-
-	response := []TimelineResponse{}
-	for reviewRows.Next() {
-		var author UserCondensed
-		var timelineElement TimelineResponse
-		var reviewBag ReviewBag
-		if err := reviewRows.Scan(&reviewBag.ID, &reviewBag.EntityID, &reviewBag.Type, &reviewBag.Colour, &reviewBag.ImageSource, &reviewBag.Title, &reviewBag.Subtitle, &reviewBag.Score, &reviewBag.Body, &timelineElement.Timestamp, &author.ID, &author.Name, &author.ImageSource); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
 
-		timelineElement.Author = author
-		timelineElement.Data = reviewBag
-		timelineElement.Type = ReviewType
+	// Fetch one extra post to find out whether there is another page
+	limit := page.Limit
+	page.Limit++
 
-		response = append(response, timelineElement)
+	posts, err := h.store.Drafts(r.Context(), ID, page)
+	if err != nil {
+		slog.Error("could not get drafts", "error", err)
+		http.Error(w, "Failed to get drafts", http.StatusInternalServerError)
+		return
 	}
 
-	listQuery := fmt.Sprintf("SELECT l.id, l.type, l.colour, l.title, l.created_on, u.id, u.name, u.image_src FROM lists l JOIN users u ON u.id = l.user_id WHERE %s ORDER BY l.created_on DESC;", whereClause)
-
-	listRows, err := db.Query(listQuery, ID)
+	response, err := h.buildFeed(r.Context(), posts, ID, limit)
 	if err != nil {
-		slog.Error("could not get timeline", "error", err)
-		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+		slog.Error("could not get drafts", "error", err)
+		http.Error(w, "Failed to get drafts", http.StatusInternalServerError)
 		return
 	}
-	defer listRows.Close()
 
-	for listRows.Next() {
-		var author UserCondensed
-		var timelineElement TimelineResponse
-		var listBag ListBag
-		if err := listRows.Scan(&listBag.ID, &listBag.Type, &listBag.Colour, &listBag.Title, &timelineElement.Timestamp, &author.ID, &author.Name, &author.ImageSource); err != nil {
-			slog.Error("could not get timeline", "error", err)
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-		listElementQuery := "SELECT entity_id, title, image_src FROM list_elements WHERE list_id = $1 ORDER BY placement ASC;"
+// parsePage reads the cursor and limit query params. An invalid cursor is
+// an error, while a missing or invalid limit falls back to defaultLimit.
+func parsePage(r *http.Request, defaultLimit int) (store.Page, error) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	page := store.Page{Limit: min(limit, maxFeedLimit)}
 
-		listElementRows, err := db.Query(listElementQuery, listBag.ID)
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		cursor, err := store.DecodeCursor(token)
 		if err != nil {
-			slog.Error("could not get timeline", "error", err)
-			http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
-			return
-		}
-		defer listElementRows.Close()
-
-		var listElements []ListElement
-		for listElementRows.Next() {
-			var listElement ListElement
-			if err := listElementRows.Scan(&listElement.EntityID, &listElement.Name, &listElement.ImageSrc); err != nil {
-				slog.Error("could not get timeline", "error", err)
-				http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-				return
-			}
-
-			listElements = append(listElements, listElement)
+			return store.Page{}, err
 		}
-
-		listBag.ListElements = listElements
-		timelineElement.Author = author
-		timelineElement.Data = listBag
-		timelineElement.Type = ListType
-
-		response = append(response, timelineElement)
+		page.After = &cursor
 	}
 
-	sort.Slice(response, func(i, j int) bool {
-		return response[i].Timestamp.After(response[j].Timestamp)
-	})
-
-	start := min(len(response), offset)
-	end := min(len(response), offset+limit)
-
-	response = response[start:end]
-
-	for i, post := range response {
-		var entityIdentifier string
-		var tableName string
-		var entityID any
-		if post.Type == ReviewType {
-			entityIdentifier = "review_id"
-			tableName = "review_likes"
+	return page, nil
+}
 
-			reviewBag, ok := post.Data.(ReviewBag)
-			if !ok {
-				slog.Error("could not get timeline")
-				http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
-				return
-			}
+// buildFeed turns posts into a page of the feed. posts may hold one more
+// post than limit, which signals that there is another page.
+func (h *handler) buildFeed(ctx context.Context, posts []store.Post, viewerID string, limit int) (FeedResponse, error) {
+	response := FeedResponse{}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		response.HasMore = true
+		response.NextCursor = store.CursorFor(posts[len(posts)-1]).Encode()
+	}
 
-			entityID = reviewBag.ID
-		} else if post.Type == ListType {
-			entityIdentifier = "list_id"
-			tableName = "list_likes"
+	items, err := h.enrichPosts(ctx, posts, viewerID)
+	if err != nil {
+		return FeedResponse{}, err
+	}
+	response.Items = items
 
-			listBag, ok := post.Data.(ListBag)
-			if !ok {
-				slog.Error("could not get timeline")
-				http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
-				return
-			}
+	return response, nil
+}
 
-			entityID = listBag.ID
+// enrichPosts fills in the like and comment counts, whether viewerID has
+// liked each post, and the elements and fork count of each list. It makes
+// the same number of queries however many posts there are.
+func (h *handler) enrichPosts(ctx context.Context, posts []store.Post, viewerID string) ([]TimelineResponse, error) {
+	reviewIDs := []int{}
+	listIDs := []string{}
+	for _, post := range posts {
+		if post.Type == store.ReviewType {
+			reviewIDs = append(reviewIDs, post.Review.ID)
+		} else if post.Type == store.ListType {
+			listIDs = append(listIDs, post.List.ID)
 		}
+	}
 
-		likeCountQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", tableName, entityIdentifier)
+	reviewLikes, err := h.store.ReviewLikeSummaries(ctx, viewerID, reviewIDs)
+	if err != nil {
+		return nil, err
+	}
+	listLikes, err := h.store.ListLikeSummaries(ctx, viewerID, listIDs)
+	if err != nil {
+		return nil, err
+	}
+	reviewComments, err := h.store.ReviewCommentCounts(ctx, reviewIDs)
+	if err != nil {
+		return nil, err
+	}
+	listComments, err := h.store.ListCommentCounts(ctx, listIDs)
+	if err != nil {
+		return nil, err
+	}
+	listElements, err := h.store.ListElements(ctx, listIDs)
+	if err != nil {
+		return nil, err
+	}
+	listForks, err := h.store.ListForkCounts(ctx, listIDs)
+	if err != nil {
+		return nil, err
+	}
 
-		var numLikes int
-		err = db.QueryRow(likeCountQuery, entityID).Scan(&numLikes)
-		if err != nil {
-			slog.Error("failed to execute SQL statement", "error", err)
-			http.Error(w, "Failed to add user", http.StatusInternalServerError)
-			return
+	items := []TimelineResponse{}
+	for _, post := range posts {
+		timelineElement := TimelineResponse{
+			Author:    post.Author,
+			Type:      post.Type,
+			Timestamp: post.Timestamp,
 		}
 
-		isLikedQuery := likeCountQuery + " AND user_id = $2"
-
-		var isLiked int
-		err = db.QueryRow(isLikedQuery, entityID, ID).Scan(&isLiked)
-		if err != nil {
-			slog.Error("failed to execute SQL statement", "error", err)
-			http.Error(w, "Failed to add user", http.StatusInternalServerError)
-			return
+		if post.Type == store.ReviewType {
+			likes := reviewLikes[post.Review.ID]
+			timelineElement.Data = post.Review
+			timelineElement.NumLikes = likes.Count
+			timelineElement.IsLiked = likes.IsLiked
+			timelineElement.NumComments = reviewComments[post.Review.ID]
+		} else if post.Type == store.ListType {
+			likes := listLikes[post.List.ID]
+			post.List.ListElements = listElements[post.List.ID]
+			post.List.NumForks = listForks[post.List.ID]
+			timelineElement.Data = post.List
+			timelineElement.NumLikes = likes.Count
+			timelineElement.IsLiked = likes.IsLiked
+			timelineElement.NumComments = listComments[post.List.ID]
 		}
 
-		response[i].NumLikes = numLikes
-		response[i].IsLiked = isLiked > 0
+		items = append(items, timelineElement)
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	return items, nil
 }
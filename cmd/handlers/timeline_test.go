@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"on-the-record-api/cmd/store"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pageThrough follows a feed's cursors from its first page to its last,
+// fetching limit posts at a time, and returns the IDs of every post in the
+// order they were served.
+func (s *testServer) pageThrough(target string, name string, limit int) []string {
+	s.t.Helper()
+
+	ids := []string{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			s.t.Fatal("feed did not end")
+		}
+
+		query := url.Values{"limit": {strconv.Itoa(limit)}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		page := decode[feedPage](s.t, s.mustDo(http.StatusOK, "GET", target+"&"+query.Encode(), name, nil))
+		if len(page.Items) > limit {
+			s.t.Fatalf("asked for %d posts, got %d", limit, len(page.Items))
+		}
+		ids = append(ids, page.ids(s.t)...)
+
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				s.t.Error("expected the last page not to have a cursor")
+			}
+			return ids
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestTimelinePaging(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+	s.addUser("carol", false)
+	s.mustDo(http.StatusNoContent, "POST", "/user/follow", "bob", followUserParams{ID: userID("alice")})
+
+	// Newest first, mixing reviews and lists, leaving out drafts and the
+	// posts of users bob doesn't follow
+	want := []string{}
+	for i := 0; i < 7; i++ {
+		var id string
+		if i%2 == 0 {
+			id = strconv.Itoa(s.addReview("alice", "album"+strconv.Itoa(i), ""))
+		} else {
+			id = s.addList("alice", "List "+strconv.Itoa(i), "")
+		}
+		want = append([]string{id}, want...)
+
+		s.addReview("alice", "draft"+strconv.Itoa(i), "private")
+		s.addReview("carol", "other"+strconv.Itoa(i), "")
+	}
+	own := strconv.Itoa(s.addReview("bob", "own", "followers"))
+	want = append([]string{own}, want...)
+
+	for _, limit := range []int{1, 2, 3, 50} {
+		if got := s.pageThrough("/timeline?", "bob", limit); !slices.Equal(got, want) {
+			t.Errorf("paging %d at a time: expected %v, got %v", limit, want, got)
+		}
+	}
+}
+
+func TestActivityPaging(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+	s.mustDo(http.StatusNoContent, "POST", "/user/follow", "bob", followUserParams{ID: userID("alice")})
+
+	public := []string{}
+	all := []string{}
+	for i := 0; i < 5; i++ {
+		id := strconv.Itoa(s.addReview("alice", "album"+strconv.Itoa(i), ""))
+		public = append([]string{id}, public...)
+		all = append([]string{id}, all...)
+
+		id = s.addList("alice", "List "+strconv.Itoa(i), "followers")
+		all = append([]string{id}, all...)
+	}
+
+	target := "/user/activity?id=" + userID("alice")
+	if got := s.pageThrough(target, "", 2); !slices.Equal(got, public) {
+		t.Errorf("anonymously: expected %v, got %v", public, got)
+	}
+	if got := s.pageThrough(target, "bob", 2); !slices.Equal(got, all) {
+		t.Errorf("as a follower: expected %v, got %v", all, got)
+	}
+}
+
+func TestFeedPagingWithTiedTimestamps(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+	s.mustDo(http.StatusNoContent, "POST", "/user/follow", "bob", followUserParams{ID: userID("alice")})
+
+	// Several reviews and lists share each timestamp, so pages have to be
+	// split between posts with the same created_on
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		createdOn := start.Add(time.Duration(i/5) * time.Minute)
+
+		err := s.store.CreateReview(ctx, store.Review{
+			UserID:     userID("alice"),
+			EntityID:   "album" + strconv.Itoa(i),
+			Type:       1,
+			Visibility: store.VisibilityPublic,
+			CreatedOn:  createdOn,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.store.CreateList(ctx, store.List{
+			UserID:       userID("alice"),
+			Type:         1,
+			Title:        "List " + strconv.Itoa(i),
+			ListElements: []store.ListElement{{EntityID: "e1", Name: "First"}},
+			Visibility:   store.VisibilityPublic,
+			CreatedOn:    createdOn,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, target := range []string{"/timeline?", "/user/activity?id=" + userID("alice")} {
+		all := s.pageThrough(target, "bob", maxFeedLimit)
+		if len(all) != 24 {
+			t.Fatalf("%s: expected 24 posts, got %d", target, len(all))
+		}
+		if unique := slices.Compact(slices.Sorted(slices.Values(all))); len(unique) != len(all) {
+			t.Fatalf("%s: expected every post once, got %v", target, all)
+		}
+
+		for limit := 1; limit <= 7; limit++ {
+			got := s.pageThrough(target, "bob", limit)
+			if !slices.Equal(got, all) {
+				t.Errorf("%s paging %d at a time: expected %v, got %v", target, limit, all, got)
+			}
+		}
+	}
+}
+
+func TestFeedRejectsInvalidCursor(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addReview("alice", "album", "")
+
+	s.mustDo(http.StatusBadRequest, "GET", "/timeline?cursor=not-a-cursor", "alice", nil)
+	s.mustDo(http.StatusBadRequest, "GET", "/user/activity?id="+userID("alice")+"&cursor=not-a-cursor", "", nil)
+}
@@ -3,216 +3,112 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log/slog"
 	"net/http"
-	"sort"
-	"strconv"
+	"on-the-record-api/cmd/middleware"
+	"on-the-record-api/cmd/store"
 	"time"
-
-	_ "github.com/lib/pq"
 )
 
 type addUserParams struct {
-	Name        string      `json:"name"`
-	ImageSource string      `json:"imageSrc"`
-	Colour      string      `json:"colour"`
-	MusicNotes  []MusicNote `json:"musicNotes"`
+	Name        string            `json:"name"`
+	ImageSource string            `json:"imageSrc"`
+	Colour      string            `json:"colour"`
+	IsPrivate   bool              `json:"isPrivate"`
+	MusicNotes  []store.MusicNote `json:"musicNotes"`
 }
 
 type updateUserParams struct {
-	ID          string      `json:"id"`
-	Name        string      `json:"name"`
-	ImageSource string      `json:"imageSrc"`
-	Colour      string      `json:"colour"`
-	MusicNotes  []MusicNote `json:"musicNotes"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	ImageSource string            `json:"imageSrc"`
+	Colour      string            `json:"colour"`
+	IsPrivate   bool              `json:"isPrivate"`
+	MusicNotes  []store.MusicNote `json:"musicNotes"`
 }
 
 type followUserParams struct {
 	ID string `json:"id"`
 }
 
-type User struct {
-	ID          string      `json:"id"`
-	Name        string      `json:"name"`
-	ImageSource string      `json:"imageSrc"`
-	Colour      string      `json:"colour"`
-	Followers   int         `json:"followers"`
-	Following   int         `json:"following"`
-	Reviews     int         `json:"reviews"`
-	Lists       int         `json:"lists"`
-	IsFollowing bool        `json:"isFollowing"`
-	MusicNotes  []MusicNote `json:"musicNotes"`
-	CreatedOn   time.Time   `json:"createdOn"`
-}
+const defaultFollowLimit = 20
 
-type UserCondensed struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	ImageSource string `json:"imageSrc"`
+// FollowUser is a user in a followers or following listing, along with
+// whether the viewer follows them.
+type FollowUser struct {
+	store.UserCondensed
+	IsFollowing bool `json:"isFollowing"`
 }
 
-type MusicNote struct {
-	EntityID    string `json:"entityId"`
-	Prompt      string `json:"prompt"`
-	ImageSource string `json:"imageSrc"`
-	Title       string `json:"title"`
-	Subtitle    string `json:"subtitle"`
+// FollowsResponse is one page of a followers or following listing.
+type FollowsResponse struct {
+	Items      []FollowUser `json:"items"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+	HasMore    bool         `json:"hasMore"`
 }
 
-func getUser(w http.ResponseWriter, r *http.Request) {
+// followPager fetches a page of the users related to userID, like
+// Store.Followers and Store.Following.
+type followPager func(ctx context.Context, userID string, mutualsOnly bool, page store.Page) ([]store.UserCondensed, error)
+
+func (h *handler) getUser(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 	ID := r.URL.Query().Get("id")
-	requestingID := r.URL.Query().Get("requesting_id")
 	if ID == "" {
 		http.Error(w, "Missing query param: id", http.StatusBadRequest)
 		return
 	}
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	selectStatement := "SELECT id, name, colour, image_src, created_on FROM users WHERE id = $1"
-	rows, err := db.Query(selectStatement, ID)
-	if err != nil {
-		slog.Error("could not get user", "error", err)
-		http.Error(w, "Failed to get user", http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
+	// Anonymous viewers aren't following anyone
+	requestingID, authenticated := middleware.UserID(r.Context())
 
-	var users []User
-	for rows.Next() {
-		var user User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Colour, &user.ImageSource, &user.CreatedOn); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
-		users = append(users, user)
-	}
-
-	if len(users) == 0 {
+	user, err := h.store.GetUser(r.Context(), ID)
+	if errors.Is(err, store.ErrNotFound) {
 		slog.Error("could not find user", "id", ID)
 		http.Error(w, "Couldn't find user", http.StatusNotFound)
 		return
 	}
-	if len(users) > 1 {
-		slog.Error("expected 1 user, found more", "matching_users", len(users), "id", ID)
-		http.Error(w, "found too many matching users", http.StatusInternalServerError)
-		return
-	}
-
-	numFollowersStatement := "SELECT count(*) FROM follower_relation WHERE followee_id = $1"
-	rows, err = db.Query(numFollowersStatement, ID)
 	if err != nil {
 		slog.Error("could not get user", "error", err)
 		http.Error(w, "Failed to get user", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-	for rows.Next() {
-		if err := rows.Scan(&users[0].Followers); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
-	}
 
-	numFollowingStatement := "SELECT count(*) FROM follower_relation WHERE follower_id = $1"
-	rows, err = db.Query(numFollowingStatement, ID)
-	if err != nil {
-		slog.Error("could not get user", "error", err)
-		http.Error(w, "Failed to get user", http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-	for rows.Next() {
-		if err := rows.Scan(&users[0].Following); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	numRows := 0
-	if requestingID != "" {
-		isCurrentUserFollowingQuery := "SELECT count(*) FROM follower_relation WHERE follower_id = $1 AND followee_id = $2"
-		rows, err = db.Query(isCurrentUserFollowingQuery, requestingID, ID)
+	if authenticated {
+		user.IsFollowing, err = h.store.IsFollowing(r.Context(), requestingID, ID)
 		if err != nil {
 			slog.Error("could not get user", "error", err)
 			http.Error(w, "Failed to get user", http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
-		for rows.Next() {
-			if err := rows.Scan(&numRows); err != nil {
-				http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-				return
-			}
-		}
-	}
-
-	users[0].IsFollowing = numRows > 0
-
-	numReviewsStatement := "SELECT count(*) FROM reviews WHERE user_id = $1"
-	rows, err = db.Query(numReviewsStatement, ID)
-	if err != nil {
-		slog.Error("could not get user", "error", err)
-		http.Error(w, "Failed to get user", http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-	for rows.Next() {
-		if err := rows.Scan(&users[0].Reviews); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
 	}
-
-	numListsStatement := "SELECT count(*) FROM lists WHERE user_id = $1"
-	rows, err = db.Query(numListsStatement, ID)
-	if err != nil {
-		slog.Error("could not get user", "error", err)
-		http.Error(w, "Failed to get user", http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-	for rows.Next() {
-		if err := rows.Scan(&users[0].Lists); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
+	if authenticated && user.IsPrivate && !user.IsFollowing {
+		user.IsRequested, err = h.store.HasRequestedFollow(r.Context(), requestingID, ID)
+		if err != nil {
+			slog.Error("could not get user", "error", err)
+			http.Error(w, "Failed to get user", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	query := "SELECT entity_id, prompt, image_src, title, subtitle FROM music_notes WHERE user_id = $1;"
-	rows, err = db.Query(query, ID)
-	if err != nil {
-		slog.Error("could not get user", "error", err)
-		http.Error(w, "Failed to get user", http.StatusInternalServerError)
-		return
-	}
-
-	musicNotes := []MusicNote{}
-	for rows.Next() {
-		var musicNote MusicNote
-		if err := rows.Scan(&musicNote.EntityID, &musicNote.Prompt, &musicNote.ImageSource, &musicNote.Title, &musicNote.Subtitle); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
+	// Private accounts only show their music notes to approved followers
+	user.MusicNotes = []store.MusicNote{}
+	if !user.IsPrivate || user.IsFollowing || requestingID == ID {
+		user.MusicNotes, err = h.store.MusicNotes(r.Context(), ID)
+		if err != nil {
+			slog.Error("could not get user", "error", err)
+			http.Error(w, "Failed to get user", http.StatusInternalServerError)
 			return
 		}
-		musicNotes = append(musicNotes, musicNote)
 	}
 
-	users[0].MusicNotes = musicNotes
-
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(users[0])
+	json.NewEncoder(w).Encode(user)
 }
 
 var featuredUsers = []followUserParams{
@@ -221,49 +117,23 @@ var featuredUsers = []followUserParams{
 	},
 }
 
-func getFeaturedUsers(w http.ResponseWriter, r *http.Request) {
+func (h *handler) getFeaturedUsers(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
-		return
+	ids := []string{}
+	for _, user := range featuredUsers {
+		ids = append(ids, user.ID)
 	}
-	defer db.Close()
-
-	whereClause := "(id = $1)"
-	params := []any{featuredUsers[0].ID}
-	for i, user := range featuredUsers {
-		if i == 0 {
-			continue
-		}
 
-		whereClause += fmt.Sprintf(" OR (id = $%d)", i+1)
-		params = append(params, user.ID)
-	}
-
-	selectStatement := "SELECT id, name, image_src FROM users WHERE " + whereClause
-	rows, err := db.Query(selectStatement, params...)
+	users, err := h.store.GetUsers(r.Context(), ids)
 	if err != nil {
 		slog.Error("could not get featured users", "error", err)
 		http.Error(w, "Failed to get user", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	var users []UserCondensed
-	for rows.Next() {
-		var user UserCondensed
-		if err := rows.Scan(&user.ID, &user.Name, &user.ImageSource); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
-		users = append(users, user)
-	}
 
 	if len(users) == 0 {
 		slog.Error("could not find featured users")
@@ -276,7 +146,7 @@ func getFeaturedUsers(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(users)
 }
 
-func addUser(w http.ResponseWriter, r *http.Request) {
+func (h *handler) addUser(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
@@ -293,108 +163,47 @@ func addUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	newUserID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
-		return
-	}
-
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	newUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
-	defer db.Close()
 
 	// Make sure user doesn't already have an account
-	checkIfUserExistsQuery := "SELECT COUNT(*) FROM users WHERE id = $1"
-
-	var count int
-	err = db.QueryRow(checkIfUserExistsQuery, newUserID).Scan(&count)
+	exists, err := h.store.UserExists(r.Context(), newUserID)
 	if err != nil {
 		slog.Error("failed to execute SQL statement", "error", err)
 		http.Error(w, "Failed to add user", http.StatusInternalServerError)
 		return
 	}
-	if count > 0 {
+	if exists {
 		slog.Error("this user already has an account", "id", newUserID)
 		http.Error(w, "this user already has an account", http.StatusBadRequest)
 		return
 	}
 
-	ctx := context.Background()
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		slog.Error("could not begin transaction", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
-		return
-	}
-
-	query := "INSERT INTO users (id, name, colour, image_src, created_on) VALUES ($1, $2, $3, $4, $5);"
-
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-		return
-	}
-	defer stmt.Close()
-
-	createdOn := time.Now()
-	_, err = stmt.Exec(newUserID, addUserBody.Name, addUserBody.Colour, addUserBody.ImageSource, createdOn)
-	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
-		return
-	}
-
-	insertMusicNoteQuery := "INSERT INTO music_notes (user_id, entity_id, prompt, image_src, title, subtitle) VALUES ($1, $2, $3, $4, $5, $6);"
-	for _, musicNote := range addUserBody.MusicNotes {
-		stmt, err := tx.Prepare(insertMusicNoteQuery)
-		if err != nil {
-			tx.Rollback()
-			slog.Error("failed to prepare SQL statement", "error", err)
-			http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-			return
-		}
-		defer stmt.Close()
-
-		_, err = stmt.Exec(newUserID, musicNote.EntityID, musicNote.Prompt, musicNote.ImageSource, musicNote.Title, musicNote.Subtitle)
-		if err != nil {
-			tx.Rollback()
-			slog.Error("failed to execute SQL statement", "error", err)
-			http.Error(w, "Failed to add user", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to commit transaction", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
-		return
-	}
-
-	resp := User{
+	user := store.User{
 		ID:          newUserID,
 		Name:        addUserBody.Name,
 		ImageSource: addUserBody.ImageSource,
 		Colour:      addUserBody.Colour,
+		IsPrivate:   addUserBody.IsPrivate,
 		MusicNotes:  addUserBody.MusicNotes,
-		CreatedOn:   createdOn,
+		CreatedOn:   time.Now(),
+	}
+
+	if err := h.store.CreateUser(r.Context(), user); err != nil {
+		slog.Error("failed to add user", "error", err)
+		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(user)
 }
 
-func updateUser(w http.ResponseWriter, r *http.Request) {
+func (h *handler) updateUser(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
@@ -411,10 +220,9 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	currentUserID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 	if currentUserID != updateUserBody.ID {
@@ -422,84 +230,30 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
 	// Make sure user exists
-	checkIfUserExistsQuery := "SELECT COUNT(*) FROM users WHERE id = $1"
-
-	var count int
-	err = db.QueryRow(checkIfUserExistsQuery, updateUserBody.ID).Scan(&count)
+	exists, err := h.store.UserExists(r.Context(), updateUserBody.ID)
 	if err != nil {
 		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
-	if count == 0 {
+	if !exists {
 		slog.Error("could not find user", "id", updateUserBody.ID)
 		http.Error(w, "user not found", http.StatusNotFound)
 		return
 	}
 
-	ctx := context.Background()
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		slog.Error("could not begin transaction", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
-		return
-	}
-
-	query := "UPDATE users SET name = $2, colour = $3, image_src = $4 WHERE id = $1;"
-
-	_, err = tx.Exec(query, updateUserBody.ID, updateUserBody.Name, updateUserBody.Colour, updateUserBody.ImageSource)
-	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
-		return
-	}
-
-	// Delete existing music notes in order to overwrite them with the new ones
-	query = "DELETE FROM music_notes WHERE user_id = $1"
-
-	_, err = tx.Exec(query, updateUserBody.ID)
-	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete user", "error", err)
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
-		return
-	}
-
-	insertMusicNoteQuery := "INSERT INTO music_notes (user_id, entity_id, prompt, image_src, title, subtitle) VALUES ($1, $2, $3, $4, $5, $6);"
-	for _, musicNote := range updateUserBody.MusicNotes {
-		stmt, err := tx.Prepare(insertMusicNoteQuery)
-		if err != nil {
-			tx.Rollback()
-			slog.Error("failed to prepare SQL statement", "error", err)
-			http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-			return
-		}
-		defer stmt.Close()
-
-		_, err = stmt.Exec(updateUserBody.ID, musicNote.EntityID, musicNote.Prompt, musicNote.ImageSource, musicNote.Title, musicNote.Subtitle)
-		if err != nil {
-			tx.Rollback()
-			slog.Error("failed to execute SQL statement", "error", err)
-			http.Error(w, "Failed to add user", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	err = tx.Commit()
+	err = h.store.UpdateUser(r.Context(), store.User{
+		ID:          updateUserBody.ID,
+		Name:        updateUserBody.Name,
+		ImageSource: updateUserBody.ImageSource,
+		Colour:      updateUserBody.Colour,
+		IsPrivate:   updateUserBody.IsPrivate,
+		MusicNotes:  updateUserBody.MusicNotes,
+	})
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to commit transaction", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		slog.Error("failed to update user", "error", err)
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
 
@@ -508,6 +262,7 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 		Name:        updateUserBody.Name,
 		ImageSource: updateUserBody.ImageSource,
 		Colour:      updateUserBody.Colour,
+		IsPrivate:   updateUserBody.IsPrivate,
 		MusicNotes:  updateUserBody.MusicNotes,
 	}
 
@@ -516,18 +271,11 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-func deleteUser(w http.ResponseWriter, r *http.Request) {
+func (h *handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
 
 	ID := r.URL.Query().Get("id")
 	if ID == "" {
@@ -535,335 +283,404 @@ func deleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	currentUserID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	currentUserID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
-	if currentUserID != ID {
+	// Admins can remove other users' accounts
+	if currentUserID != ID && !middleware.HasScope(r.Context(), "admin:users") {
 		http.Error(w, "Can only delete your own user", http.StatusForbidden)
 		return
 	}
 
-	ctx := context.Background()
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		slog.Error("could not begin transaction", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	if err := h.store.DeleteUser(r.Context(), ID); err != nil {
+		slog.Error("could not delete user", "error", err)
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
 		return
 	}
 
-	query := "DELETE FROM music_notes WHERE user_id = $1"
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
 
-	_, err = tx.Exec(query, ID)
-	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete user", "error", err)
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+func (h *handler) searchUser(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
 		return
 	}
 
-	query = "DELETE FROM list_elements WHERE user_id = $1"
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		http.Error(w, "Missing query param: query", http.StatusBadRequest)
+		return
+	}
 
-	_, err = tx.Exec(query, ID)
+	users, err := h.store.SearchUsers(r.Context(), query, 5)
 	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete user", "error", err)
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		slog.Error("could not get user", "error", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
 		return
 	}
 
-	query = "DELETE FROM lists WHERE user_id = $1"
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
 
-	_, err = tx.Exec(query, ID)
-	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete user", "error", err)
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+func (h *handler) getActivity(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	ID := r.URL.Query().Get("id")
+	if ID == "" {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
 		return
 	}
 
-	query = "DELETE FROM reviews WHERE user_id = $1"
+	// An anonymous viewer gets like counts without isLiked
+	requestingID, _ := middleware.UserID(r.Context())
 
-	_, err = tx.Exec(query, ID)
+	canView, err := h.canViewPosts(r.Context(), requestingID, ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Couldn't find user", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete user", "error", err)
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		slog.Error("could not get activity", "error", err)
+		http.Error(w, "Failed to get activity", http.StatusInternalServerError)
+		return
+	}
+	if !canView {
+		http.Error(w, "This account is private", http.StatusForbidden)
 		return
 	}
 
-	query = "DELETE FROM follower_relation WHERE follower_id = $1 OR followee_id = $1"
+	// Followers also see the posts meant only for them
+	asFollower := requestingID == ID
+	if !asFollower && requestingID != "" {
+		asFollower, err = h.store.IsFollowing(r.Context(), requestingID, ID)
+		if err != nil {
+			slog.Error("could not get activity", "error", err)
+			http.Error(w, "Failed to get activity", http.StatusInternalServerError)
+			return
+		}
+	}
 
-	_, err = tx.Exec(query, ID)
+	page, err := parsePage(r, defaultFeedLimit)
 	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete user", "error", err)
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
 		return
 	}
 
-	query = "DELETE FROM users WHERE id = $1"
+	// Fetch one extra post to find out whether there is another page
+	limit := page.Limit
+	page.Limit++
 
-	_, err = tx.Exec(query, ID)
+	posts, err := h.store.Activity(r.Context(), ID, asFollower, page)
 	if err != nil {
-		tx.Rollback()
-		slog.Error("could not delete user", "error", err)
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		slog.Error("could not get activity", "error", err)
+		http.Error(w, "Failed to get activity", http.StatusInternalServerError)
 		return
 	}
 
-	err = tx.Commit()
+	response, err := h.buildFeed(r.Context(), posts, requestingID, limit)
 	if err != nil {
-		tx.Rollback()
-		slog.Error("failed to commit transaction", "error", err)
-		http.Error(w, "Failed to add user", http.StatusInternalServerError)
+		slog.Error("could not get activity", "error", err)
+		http.Error(w, "Failed to get activity", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
-func searchUser(w http.ResponseWriter, r *http.Request) {
+func (h *handler) followUser(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		http.Error(w, "Missing query param: query", http.StatusBadRequest)
+	followerID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	db, err := connectToDB()
+	var followUserBody followUserParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&followUserBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
+
+	// Neither side of a block can follow the other
+	blocked, err := h.store.IsBlocked(r.Context(), followerID, followUserBody.ID)
 	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+		slog.Error("failed to check for blocks", "error", err)
+		http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, "user cannot follow this user", http.StatusForbidden)
 		return
 	}
-	defer db.Close()
 
-	selectStatement := "SELECT id, name, image_src FROM users WHERE name ILIKE FORMAT('%s%%', $1::text)"
-	rows, err := db.Query(selectStatement, query)
+	isPrivate, err := h.store.IsPrivate(r.Context(), followUserBody.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		slog.Error("could not get user", "error", err)
-		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		slog.Error("failed to check for private account", "error", err)
+		http.Error(w, "Failed to follow user", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	var users = []UserCondensed{}
-	for rows.Next() {
-		var user UserCondensed
-		if err := rows.Scan(&user.ID, &user.Name, &user.ImageSource); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
+	// Private accounts have to approve new followers first
+	if isPrivate {
+		following, err := h.store.IsFollowing(r.Context(), followerID, followUserBody.ID)
+		if err != nil {
+			slog.Error("failed to check for existing follow", "error", err)
+			http.Error(w, "Failed to follow user", http.StatusInternalServerError)
 			return
 		}
-		users = append(users, user)
+		if !following {
+			if err := h.store.RequestFollow(r.Context(), followerID, followUserBody.ID); err != nil {
+				slog.Error("failed to request follow", "error", err)
+				http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+				return
+			}
+
+			h.notify(r.Context(), store.Notification{
+				UserID:  followUserBody.ID,
+				ActorID: followerID,
+				Kind:    store.NotificationFollowRequest,
+			})
 
-		if len(users) == 5 {
-			break
+			w.WriteHeader(http.StatusAccepted)
+			w.Header().Set("Content-Type", "application/json")
+			return
 		}
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if err := h.store.Follow(r.Context(), followerID, followUserBody.ID); err != nil {
+		slog.Error("failed to follow user", "error", err)
+		http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+		return
+	}
+
+	h.notify(r.Context(), store.Notification{
+		UserID:  followUserBody.ID,
+		ActorID: followerID,
+		Kind:    store.NotificationFollow,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(users)
 }
 
-func getActivity(w http.ResponseWriter, r *http.Request) {
+func (h *handler) unfollowUser(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
-	ID := r.URL.Query().Get("id")
-	requestingID := r.URL.Query().Get("requesting_id")
-	if ID == "" || requestingID == "" {
-		http.Error(w, "Missing query params: id and requesting_id", http.StatusBadRequest)
+	unfollowerID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil || limit < 0 {
-		limit = 3
+	var unfollowUserBody followUserParams
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&unfollowUserBody); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
 	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			slog.Error("failed to close request body", "error", err)
+		}
+	}()
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	if err := h.store.Unfollow(r.Context(), unfollowerID, unfollowUserBody.ID); err != nil {
+		slog.Error("failed to unfollow user", "error", err)
+		http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
 
-	reviewQuery := "SELECT r.id, r.entity_id, r.type, r.colour, r.image_src, r.title, r.subtitle, r.score, r.body, r.created_on, u.id, u.name, u.image_src FROM reviews r JOIN users u ON u.id = r.user_id WHERE r.user_id = $1 ORDER BY r.created_on DESC;"
+	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+}
 
-	reviewRows, err := db.Query(reviewQuery, ID)
-	if err != nil {
-		slog.Error("could not get timeline", "error", err)
-		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+func (h *handler) getFollowers(w http.ResponseWriter, r *http.Request) {
+	h.getFollows(w, r, h.store.Followers)
+}
+
+func (h *handler) getFollowing(w http.ResponseWriter, r *http.Request) {
+	h.getFollows(w, r, h.store.Following)
+}
+
+// getFollows serves a page of the users that fetch returns for the id query
+// param. Passing mutuals=true keeps only the users that follow each other.
+func (h *handler) getFollows(w http.ResponseWriter, r *http.Request, fetch followPager) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	ID := r.URL.Query().Get("id")
+	if ID == "" {
+		http.Error(w, "Missing query param: id", http.StatusBadRequest)
 		return
 	}
-	defer reviewRows.Close()
+	mutualsOnly := r.URL.Query().Get("mutuals") == "true"
 
-	response := []TimelineResponse{}
-	for reviewRows.Next() {
-		var author UserCondensed
-		var timelineElement TimelineResponse
-		var reviewBag ReviewBag
-		if err := reviewRows.Scan(&reviewBag.ID, &reviewBag.EntityID, &reviewBag.Type, &reviewBag.Colour, &reviewBag.ImageSource, &reviewBag.Title, &reviewBag.Subtitle, &reviewBag.Score, &reviewBag.Body, &timelineElement.Timestamp, &author.ID, &author.Name, &author.ImageSource); err != nil {
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
+	// Anonymous viewers aren't following anyone
+	requestingID, authenticated := middleware.UserID(r.Context())
 
-		timelineElement.Author = author
-		timelineElement.Data = reviewBag
-		timelineElement.Type = ReviewType
+	page, err := parsePage(r, defaultFollowLimit)
+	if err != nil {
+		http.Error(w, "Invalid query param: cursor", http.StatusBadRequest)
+		return
+	}
 
-		response = append(response, timelineElement)
+	exists, err := h.store.UserExists(r.Context(), ID)
+	if err != nil {
+		slog.Error("could not get follows", "error", err)
+		http.Error(w, "Failed to get users", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Couldn't find user", http.StatusNotFound)
+		return
 	}
 
-	listQuery := "SELECT l.id, l.type, l.colour, l.title, l.created_on, u.id, u.name, u.image_src FROM lists l JOIN users u ON u.id = l.user_id WHERE l.user_id = $1 ORDER BY l.created_on DESC;"
+	// Fetch one extra user to find out whether there is another page
+	limit := page.Limit
+	page.Limit++
 
-	listRows, err := db.Query(listQuery, ID)
+	users, err := fetch(r.Context(), ID, mutualsOnly, page)
 	if err != nil {
-		slog.Error("could not get timeline", "error", err)
-		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+		slog.Error("could not get follows", "error", err)
+		http.Error(w, "Failed to get users", http.StatusInternalServerError)
 		return
 	}
-	defer listRows.Close()
 
-	for listRows.Next() {
-		var author UserCondensed
-		var timelineElement TimelineResponse
-		var listBag ListBag
-		if err := listRows.Scan(&listBag.ID, &listBag.Type, &listBag.Colour, &listBag.Title, &timelineElement.Timestamp, &author.ID, &author.Name, &author.ImageSource); err != nil {
-			slog.Error("could not get timeline", "error", err)
-			http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-			return
-		}
+	response := FollowsResponse{Items: []FollowUser{}}
+	if len(users) > limit {
+		users = users[:limit]
+		response.HasMore = true
+		response.NextCursor = store.CursorForUser(users[len(users)-1]).Encode()
+	}
 
-		listElementQuery := "SELECT entity_id, title, image_src FROM list_elements WHERE list_id = $1 ORDER BY placement ASC;"
+	following := map[string]bool{}
+	if authenticated {
+		ids := []string{}
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
 
-		listElementRows, err := db.Query(listElementQuery, listBag.ID)
+		following, err = h.store.FollowingAmong(r.Context(), requestingID, ids)
 		if err != nil {
-			slog.Error("could not get timeline", "error", err)
-			http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+			slog.Error("could not get follows", "error", err)
+			http.Error(w, "Failed to get users", http.StatusInternalServerError)
 			return
 		}
-		defer listElementRows.Close()
-
-		var listElements []ListElement
-		for listElementRows.Next() {
-			var listElement ListElement
-			if err := listElementRows.Scan(&listElement.EntityID, &listElement.Name, &listElement.ImageSrc); err != nil {
-				slog.Error("could not get timeline", "error", err)
-				http.Error(w, "Failed to scan row", http.StatusInternalServerError)
-				return
-			}
-
-			listElements = append(listElements, listElement)
-		}
-
-		listBag.ListElements = listElements
-		timelineElement.Author = author
-		timelineElement.Data = listBag
-		timelineElement.Type = ListType
-
-		response = append(response, timelineElement)
 	}
 
-	sort.Slice(response, func(i, j int) bool {
-		return response[i].Timestamp.After(response[j].Timestamp)
-	})
-
-	start := min(len(response), offset)
-	end := min(len(response), offset+limit)
-
-	response = response[start:end]
-
-	for i, post := range response {
-		var entityIdentifier string
-		var tableName string
-		var entityID any
-		if post.Type == ReviewType {
-			entityIdentifier = "review_id"
-			tableName = "review_likes"
-
-			reviewBag, ok := post.Data.(ReviewBag)
-			if !ok {
-				slog.Error("could not get timeline")
-				http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
-				return
-			}
+	for _, user := range users {
+		response.Items = append(response.Items, FollowUser{UserCondensed: user, IsFollowing: following[user.ID]})
+	}
 
-			entityID = reviewBag.ID
-		} else if post.Type == ListType {
-			entityIdentifier = "list_id"
-			tableName = "list_likes"
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-			listBag, ok := post.Data.(ListBag)
-			if !ok {
-				slog.Error("could not get timeline")
-				http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
-				return
-			}
+// canViewPosts reports whether viewerID may see what authorID has posted.
+// Private accounts only show their posts to themselves and their
+// followers.
+func (h *handler) canViewPosts(ctx context.Context, viewerID string, authorID string) (bool, error) {
+	isPrivate, err := h.store.IsPrivate(ctx, authorID)
+	if err != nil {
+		return false, err
+	}
+	if !isPrivate || viewerID == authorID {
+		return true, nil
+	}
+	if viewerID == "" {
+		return false, nil
+	}
 
-			entityID = listBag.ID
-		}
+	return h.store.IsFollowing(ctx, viewerID, authorID)
+}
 
-		likeCountQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", tableName, entityIdentifier)
+// canViewPost reports whether viewerID may see the post, given its
+// visibility and whether its author has a private account.
+func (h *handler) canViewPost(ctx context.Context, viewerID string, post store.Post) (bool, error) {
+	if viewerID != "" && viewerID == post.Author.ID {
+		return true, nil
+	}
 
-		var numLikes int
-		err = db.QueryRow(likeCountQuery, entityID).Scan(&numLikes)
-		if err != nil {
-			slog.Error("failed to execute SQL statement", "error", err)
-			http.Error(w, "Failed to add user", http.StatusInternalServerError)
-			return
+	switch post.Visibility() {
+	case store.VisibilityPrivate:
+		return false, nil
+	case store.VisibilityFollowers:
+		if viewerID == "" {
+			return false, nil
 		}
+		return h.store.IsFollowing(ctx, viewerID, post.Author.ID)
+	default:
+		return h.canViewPosts(ctx, viewerID, post.Author.ID)
+	}
+}
 
-		isLikedQuery := likeCountQuery + " AND user_id = $2"
+func (h *handler) getFollowRequests(w http.ResponseWriter, r *http.Request) {
+	setupCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
 
-		var isLiked int
-		err = db.QueryRow(isLikedQuery, entityID, requestingID).Scan(&isLiked)
-		if err != nil {
-			slog.Error("failed to execute SQL statement", "error", err)
-			http.Error(w, "Failed to add user", http.StatusInternalServerError)
-			return
-		}
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
+		return
+	}
 
-		response[i].NumLikes = numLikes
-		response[i].IsLiked = isLiked > 0
+	requests, err := h.store.FollowRequests(r.Context(), userID)
+	if err != nil {
+		slog.Error("could not get follow requests", "error", err)
+		http.Error(w, "Failed to get follow requests", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(requests)
 }
 
-func followUser(w http.ResponseWriter, r *http.Request) {
+func (h *handler) approveFollowRequest(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
 
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	followerID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	var followUserBody followUserParams
+	var requestBody followUserParams
 	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&followUserBody); err != nil {
+	if err := decoder.Decode(&requestBody); err != nil {
 		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
 		return
 	}
@@ -873,28 +690,14 @@ func followUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	err := h.store.ApproveFollowRequest(r.Context(), userID, requestBody.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Follow request not found", http.StatusNotFound)
 		return
 	}
-	defer db.Close()
-
-	query := "INSERT INTO follower_relation (follower_id, followee_id) VALUES ($1, $2);"
-
-	stmt, err := db.Prepare(query)
 	if err != nil {
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-		return
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(followerID, followUserBody.ID)
-	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+		slog.Error("failed to approve follow request", "error", err)
+		http.Error(w, "Failed to approve follow request", http.StatusInternalServerError)
 		return
 	}
 
@@ -902,21 +705,21 @@ func followUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 }
 
-func unfollowUser(w http.ResponseWriter, r *http.Request) {
+func (h *handler) denyFollowRequest(w http.ResponseWriter, r *http.Request) {
 	setupCORS(w, r)
 	if r.Method == "OPTIONS" {
 		return
 	}
-	token := r.Header["Authorization"][0][len("Bearer: "):]
-	unfollowerID, err := extractUserIDFromJWTPayload(token)
-	if err != nil {
-		http.Error(w, "Malformed authentication token", http.StatusUnauthorized)
+
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Missing authentication token", http.StatusUnauthorized)
 		return
 	}
 
-	var unfollowUserBody followUserParams
+	var requestBody followUserParams
 	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&unfollowUserBody); err != nil {
+	if err := decoder.Decode(&requestBody); err != nil {
 		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
 		return
 	}
@@ -926,28 +729,14 @@ func unfollowUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	db, err := connectToDB()
-	if err != nil {
-		slog.Error("could not connect to Postgres", "error", err)
-		http.Error(w, "Failed to connect to Postgres", http.StatusInternalServerError)
+	err := h.store.DenyFollowRequest(r.Context(), userID, requestBody.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Follow request not found", http.StatusNotFound)
 		return
 	}
-	defer db.Close()
-
-	query := "DELETE FROM follower_relation WHERE follower_id = $1 AND followee_id = $2;"
-
-	stmt, err := db.Prepare(query)
 	if err != nil {
-		slog.Error("failed to prepare SQL statement", "error", err)
-		http.Error(w, "Failed to prepare SQL statement", http.StatusInternalServerError)
-		return
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(unfollowerID, unfollowUserBody.ID)
-	if err != nil {
-		slog.Error("failed to execute SQL statement", "error", err)
-		http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+		slog.Error("failed to deny follow request", "error", err)
+		http.Error(w, "Failed to deny follow request", http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestFollow(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+	s.addUser("bob", false)
+
+	id := s.addReview("alice", "album", "")
+
+	timeline := decode[feedPage](t, s.mustDo(http.StatusOK, "GET", "/timeline", "bob", nil))
+	if len(timeline.Items) != 0 {
+		t.Fatalf("expected an empty timeline before following, got %v", timeline.ids(t))
+	}
+
+	s.mustDo(http.StatusNoContent, "POST", "/user/follow", "bob", followUserParams{ID: userID("alice")})
+
+	user := decode[struct {
+		IsFollowing  bool `json:"isFollowing"`
+		NumFollowers int  `json:"followers"`
+	}](t, s.mustDo(http.StatusOK, "GET", "/user?id="+userID("alice"), "bob", nil))
+	if !user.IsFollowing || user.NumFollowers != 1 {
+		t.Errorf("expected bob to be alice's only follower, got %+v", user)
+	}
+
+	timeline = decode[feedPage](t, s.mustDo(http.StatusOK, "GET", "/timeline", "bob", nil))
+	if ids := timeline.ids(t); !slices.Equal(ids, []string{strconv.Itoa(id)}) {
+		t.Errorf("expected alice's review on bob's timeline, got %v", ids)
+	}
+
+	s.mustDo(http.StatusNoContent, "POST", "/user/unfollow", "bob", followUserParams{ID: userID("alice")})
+
+	timeline = decode[feedPage](t, s.mustDo(http.StatusOK, "GET", "/timeline", "bob", nil))
+	if len(timeline.Items) != 0 {
+		t.Errorf("expected an empty timeline after unfollowing, got %v", timeline.ids(t))
+	}
+
+	s.mustDo(http.StatusNotFound, "POST", "/user/follow", "bob", followUserParams{ID: userID("nobody")})
+}
+
+func TestGoingPublicApprovesFollowRequests(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", true)
+	s.addUser("bob", false)
+
+	s.mustDo(http.StatusAccepted, "POST", "/user/follow", "bob", followUserParams{ID: userID("alice")})
+
+	type following struct {
+		IsFollowing  bool `json:"isFollowing"`
+		NumFollowers int  `json:"followers"`
+	}
+	if got := decode[following](t, s.mustDo(http.StatusOK, "GET", "/user?id="+userID("alice"), "bob", nil)); got != (following{}) {
+		t.Fatalf("expected the request to be pending, got %+v", got)
+	}
+
+	s.mustDo(http.StatusOK, "PUT", "/user", "alice", updateUserParams{ID: userID("alice"), Name: "alice", IsPrivate: false})
+
+	if got := decode[following](t, s.mustDo(http.StatusOK, "GET", "/user?id="+userID("alice"), "bob", nil)); got != (following{IsFollowing: true, NumFollowers: 1}) {
+		t.Errorf("expected bob to follow alice after the account went public, got %+v", got)
+	}
+
+	requests := decode[[]struct {
+		ID string `json:"id"`
+	}](t, s.mustDo(http.StatusOK, "GET", "/user/follow-requests", "alice", nil))
+	if len(requests) != 0 {
+		t.Errorf("expected no pending follow requests, got %+v", requests)
+	}
+}
+
+func TestFollowsOfUnknownUser(t *testing.T) {
+	s := newTestServer(t)
+	s.addUser("alice", false)
+
+	s.mustDo(http.StatusOK, "GET", "/user/followers?id="+userID("alice"), "", nil)
+	s.mustDo(http.StatusNotFound, "GET", "/user/followers?id="+userID("nobody"), "", nil)
+	s.mustDo(http.StatusNotFound, "GET", "/user/following?id="+userID("nobody"), "alice", nil)
+}
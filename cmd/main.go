@@ -5,6 +5,10 @@ import (
 	"log"
 	"net/http"
 	"on-the-record-api/cmd/handlers"
+	"on-the-record-api/cmd/store"
+	"on-the-record-api/cmd/store/memory"
+	"on-the-record-api/cmd/store/postgres"
+	"os"
 
 	"github.com/joho/godotenv"
 )
@@ -17,7 +21,25 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
-	handlers.RegisterHandlers()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	var s store.Store
+	if os.Getenv("STORAGE") == "memory" {
+		s = memory.New()
+	} else {
+		db, err := postgres.Open(postgres.ConfigFromEnv())
+		if err != nil {
+			log.Fatalf("Failed to connect to Postgres: %v", err)
+		}
+		defer db.Close()
+
+		s = postgres.New(db)
+	}
+
+	handlers.RegisterHandlers(s)
 
 	fmt.Printf("Listening on port %d...\n", port)
 	err = http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
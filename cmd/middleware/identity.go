@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+type userIDKey struct{}
+
+// UserID returns the ID of the authenticated user making the request. ok
+// is false when the request did not carry a valid token.
+func UserID(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(userIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Claims returns the validated claims of the request's token.
+func Claims(ctx context.Context) (*validator.ValidatedClaims, bool) {
+	claims, ok := ctx.Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	return claims, ok
+}
+
+// withUserID adds the ID of the token's subject to the request context.
+// It must run after the JWT has been validated.
+func withUserID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := Claims(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey{}, translateSubToUserID(claims.RegisteredClaims.Subject))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func translateSubToUserID(sub string) string {
+	authSections := strings.Split(sub, "|")
+
+	if len(authSections) != 2 {
+		slog.Error("Unexpected auth0 ID format")
+		return sub
+	}
+
+	if authSections[0] == "auth0" {
+		return "0" + authSections[1]
+	} else if authSections[0] == "facebook" {
+		return "1" + authSections[1]
+	} else if authSections[0] == "google-oauth2" {
+		return "2" + authSections[1]
+	}
+
+	slog.Error("Unrecognized auth provider: " + authSections[0])
+	return sub
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
@@ -24,46 +25,84 @@ func (c CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
+var (
+	jwtValidator     *validator.Validator
+	jwtValidatorOnce sync.Once
+)
+
+// getValidator returns the JWT validator shared by every route, so that
+// they all use the same cache of signing keys.
+func getValidator() *validator.Validator {
+	jwtValidatorOnce.Do(func() {
+		issuerURL, err := url.Parse("https://" + os.Getenv("AUTH0_DOMAIN") + "/")
+		if err != nil {
+			log.Fatalf("Failed to parse the issuer url: %v", err)
+		}
+
+		provider := jwks.NewCachingProvider(issuerURL, 5*time.Minute)
+
+		jwtValidator, err = validator.New(
+			provider.KeyFunc,
+			validator.RS256,
+			issuerURL.String(),
+			[]string{os.Getenv("AUTH0_AUDIENCE")},
+			validator.WithCustomClaims(
+				func() validator.CustomClaims {
+					return &CustomClaims{}
+				},
+			),
+			validator.WithAllowedClockSkew(time.Minute),
+		)
+		if err != nil {
+			log.Fatalf("Failed to set up the jwt validator")
+		}
+	})
+
+	return jwtValidator
+}
+
+// UseValidator makes every route check tokens with v instead of the
+// validator for the Auth0 tenant in the environment. Tests use it to accept
+// tokens they sign themselves. It must be called before any routes are set
+// up.
+func UseValidator(v *validator.Validator) {
+	jwtValidatorOnce.Do(func() {})
+	jwtValidator = v
+}
 
 // EnsureValidToken is a middleware that will check the validity of our JWT.
+// Handlers behind it can get the caller's user ID with UserID.
 func EnsureValidToken() func(next http.Handler) http.Handler {
-	issuerURL, err := url.Parse("https://" + os.Getenv("AUTH0_DOMAIN") + "/")
-	if err != nil {
-		log.Fatalf("Failed to parse the issuer url: %v", err)
-	}
-
-	provider := jwks.NewCachingProvider(issuerURL, 5*time.Minute)
-
-	jwtValidator, err := validator.New(
-		provider.KeyFunc,
-		validator.RS256,
-		issuerURL.String(),
-		[]string{os.Getenv("AUTH0_AUDIENCE")},
-		validator.WithCustomClaims(
-			func() validator.CustomClaims {
-				return &CustomClaims{}
-			},
-		),
-		validator.WithAllowedClockSkew(time.Minute),
+	middleware := jwtmiddleware.New(
+		getValidator().ValidateToken,
+		jwtmiddleware.WithErrorHandler(tokenErrorHandler),
 	)
-	if err != nil {
-		log.Fatalf("Failed to set up the jwt validator")
-	}
-
-	errorHandler := func(w http.ResponseWriter, r *http.Request, err error) {
-		log.Printf("Encountered error while validating JWT: %v", err)
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(`{"message":"Failed to validate JWT."}`))
+	return func(next http.Handler) http.Handler {
+		return middleware.CheckJWT(withUserID(next))
 	}
+}
 
+// OptionalToken is a middleware for routes that anyone can call, but whose
+// response depends on who is asking. A request without a token is passed
+// through anonymously, in which case UserID reports ok as false. A token
+// that is present but invalid is still rejected.
+func OptionalToken() func(next http.Handler) http.Handler {
 	middleware := jwtmiddleware.New(
-		jwtValidator.ValidateToken,
-		jwtmiddleware.WithErrorHandler(errorHandler),
+		getValidator().ValidateToken,
+		jwtmiddleware.WithCredentialsOptional(true),
+		jwtmiddleware.WithErrorHandler(tokenErrorHandler),
 	)
 
 	return func(next http.Handler) http.Handler {
-		return middleware.CheckJWT(next)
+		return middleware.CheckJWT(withUserID(next))
 	}
-}
\ No newline at end of file
+}
+
+func tokenErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Encountered error while validating JWT: %v", err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write([]byte(`{"message":"Failed to validate JWT."}`))
+}
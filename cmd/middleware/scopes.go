@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scopes returns the scopes granted to the request's token.
+func Scopes(ctx context.Context) []string {
+	claims, ok := Claims(ctx)
+	if !ok {
+		return nil
+	}
+
+	customClaims, ok := claims.CustomClaims.(*CustomClaims)
+	if !ok {
+		return nil
+	}
+
+	return strings.Fields(customClaims.Scope)
+}
+
+// HasScope reports whether the request's token was granted the given scope.
+func HasScope(ctx context.Context, scope string) bool {
+	return slices.Contains(Scopes(ctx), scope)
+}
+
+// RequireScopes is a middleware that rejects requests whose token is missing
+// any of the given scopes. It must run after EnsureValidToken.
+func RequireScopes(scopes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted := Scopes(r.Context())
+
+			missing := []string{}
+			for _, scope := range scopes {
+				if !slices.Contains(granted, scope) {
+					missing = append(missing, scope)
+				}
+			}
+
+			if len(missing) > 0 {
+				slog.Error("token is missing required scopes", "required", scopes, "missing", missing)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(struct {
+					Message        string   `json:"message"`
+					RequiredScopes []string `json:"requiredScopes"`
+					MissingScopes  []string `json:"missingScopes"`
+				}{
+					Message:        "Insufficient scope.",
+					RequiredScopes: scopes,
+					MissingScopes:  missing,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+// requestWithScope returns a request whose context holds validated claims
+// granting scope, as EnsureValidToken would leave it.
+func requestWithScope(scope string) *http.Request {
+	r := httptest.NewRequest("POST", "/review", nil)
+	claims := &validator.ValidatedClaims{CustomClaims: &CustomClaims{Scope: scope}}
+	return r.WithContext(context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, claims))
+}
+
+// serveWithScopes runs the request through RequireScopes and reports the
+// response and whether the next handler was called.
+func serveWithScopes(r *http.Request, scopes ...string) (*httptest.ResponseRecorder, bool) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	RequireScopes(scopes...)(next).ServeHTTP(w, r)
+	return w, called
+}
+
+type insufficientScope struct {
+	Message        string   `json:"message"`
+	RequiredScopes []string `json:"requiredScopes"`
+	MissingScopes  []string `json:"missingScopes"`
+}
+
+func TestRequireScopesGranted(t *testing.T) {
+	w, called := serveWithScopes(requestWithScope("read:reviews write:reviews write:likes"), "write:reviews", "write:likes")
+
+	if !called {
+		t.Fatalf("expected the request to be passed on, got status %d: %s", w.Code, w.Body.String())
+	}
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+}
+
+func TestRequireScopesRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *http.Request
+		missing []string
+	}{
+		{
+			name:    "one scope missing",
+			request: requestWithScope("read:reviews write:reviews"),
+			missing: []string{"write:likes"},
+		},
+		{
+			name:    "no claims",
+			request: httptest.NewRequest("POST", "/review", nil),
+			missing: []string{"write:reviews", "write:likes"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, called := serveWithScopes(test.request, "write:reviews", "write:likes")
+
+			if called {
+				t.Fatal("expected the request not to be passed on")
+			}
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("expected a JSON response, got %q", contentType)
+			}
+
+			var body insufficientScope
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(body.RequiredScopes, []string{"write:reviews", "write:likes"}) {
+				t.Errorf("expected requiredScopes to be every required scope, got %v", body.RequiredScopes)
+			}
+			if !slices.Equal(body.MissingScopes, test.missing) {
+				t.Errorf("expected missingScopes %v, got %v", test.missing, body.MissingScopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"on-the-record-api/cmd/store/postgres"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | version | baseline <version>"
+
+// runMigrate implements the `migrate` subcommand, which brings the
+// database's schema up or down using the migrations embedded in the binary.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	db, err := postgres.Open(postgres.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to connect to Postgres: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := postgres.MigrateUp(ctx, db)
+		for _, name := range applied {
+			fmt.Printf("Applied %s\n", name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is already up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatal(migrateUsage)
+			}
+		}
+
+		rolledBack, err := postgres.MigrateDown(ctx, db, steps)
+		for _, name := range rolledBack {
+			fmt.Printf("Rolled back %s\n", name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	case "baseline":
+		if len(args) < 2 {
+			log.Fatal(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 1 {
+			log.Fatal(migrateUsage)
+		}
+
+		recorded, err := postgres.Baseline(ctx, db, version)
+		if err != nil {
+			log.Fatalf("Baseline failed: %v", err)
+		}
+		for _, name := range recorded {
+			fmt.Printf("Recorded %s as applied\n", name)
+		}
+	case "version":
+		version, err := postgres.SchemaVersion(ctx, db)
+		if err != nil {
+			log.Fatalf("Failed to get schema version: %v", err)
+		}
+		fmt.Printf("Schema version: %d\n", version)
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor token can't be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a feed. Feeds are ordered newest first, with
+// ties broken by Type ascending and then ID descending, so a cursor
+// identifies exactly one post even when several share a timestamp.
+// Comments are paged with the same cursor, oldest first by CreatedOn and
+// then ID, leaving Type unset. Notifications are paged newest first by
+// CreatedOn, which holds when they were last updated, and then ID. Lists
+// of users are paged by ID alone.
+type Cursor struct {
+	CreatedOn time.Time
+	Type      int
+	ID        string
+}
+
+// Page selects the posts that come after the cursor, if any.
+type Page struct {
+	After *Cursor
+	Limit int
+}
+
+type cursorToken struct {
+	CreatedOn time.Time `json:"t"`
+	Type      int       `json:"k"`
+	ID        string    `json:"i"`
+}
+
+// CursorFor returns the cursor pointing at the given post.
+func CursorFor(post Post) Cursor {
+	cursor := Cursor{CreatedOn: post.Timestamp, Type: post.Type}
+	if post.Type == ReviewType {
+		cursor.ID = strconv.Itoa(post.Review.ID)
+	} else {
+		cursor.ID = post.List.ID
+	}
+
+	return cursor
+}
+
+// CursorForComment returns the cursor pointing at the given comment.
+func CursorForComment(comment CommentBag) Cursor {
+	return Cursor{CreatedOn: comment.CreatedOn, ID: strconv.Itoa(comment.ID)}
+}
+
+// CursorForNotification returns the cursor pointing at the given
+// notification.
+func CursorForNotification(notification NotificationBag) Cursor {
+	return Cursor{CreatedOn: notification.UpdatedOn, ID: strconv.Itoa(notification.ID)}
+}
+
+// CursorForUser returns the cursor pointing at the given user.
+func CursorForUser(user UserCondensed) Cursor {
+	return Cursor{ID: user.ID}
+}
+
+// Encode returns the cursor as an opaque token that is safe to put in a
+// URL.
+func (c Cursor) Encode() string {
+	token, _ := json.Marshal(cursorToken{CreatedOn: c.CreatedOn, Type: c.Type, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode.
+func DecodeCursor(token string) (Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var parsed cursorToken
+	if err := json.Unmarshal(decoded, &parsed); err != nil || parsed.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedOn: parsed.CreatedOn, Type: parsed.Type, ID: parsed.ID}, nil
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdOn := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+
+	cursors := []Cursor{
+		CursorFor(Post{Type: ReviewType, Timestamp: createdOn, Review: ReviewBag{ID: 42}}),
+		CursorFor(Post{Type: ListType, Timestamp: createdOn, List: ListBag{ID: "3f2b9c1e-list"}}),
+		CursorForComment(CommentBag{ID: 7, CreatedOn: createdOn}),
+		CursorForUser(UserCondensed{ID: "0alice"}),
+	}
+
+	for _, cursor := range cursors {
+		decoded, err := DecodeCursor(cursor.Encode())
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %v", cursor, err)
+			continue
+		}
+		if !decoded.CreatedOn.Equal(cursor.CreatedOn) || decoded.Type != cursor.Type || decoded.ID != cursor.ID {
+			t.Errorf("expected %+v, got %+v", cursor, decoded)
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	tokens := map[string]string{
+		"not base64":  "not a cursor!",
+		"not JSON":    base64.RawURLEncoding.EncodeToString([]byte("cursor")),
+		"missing ID":  base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2024-03-01T12:30:00Z","k":1}`)),
+		"wrong types": base64.RawURLEncoding.EncodeToString([]byte(`{"t":1,"k":"1","i":1}`)),
+	}
+
+	for name, token := range tokens {
+		if _, err := DecodeCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Lists must have at least MinListElements and at most MaxListElements
+// elements.
+const (
+	MinListElements = 1
+	MaxListElements = 100
+)
+
+// MaxListElementNoteLength is the most characters a list element's note
+// can have.
+const MaxListElementNoteLength = 500
+
+// ErrInvalidListEdit is returned when a list edit refers to a placement
+// the list doesn't have, would leave the list too short or too long, or
+// has a note that is too long.
+var ErrInvalidListEdit = errors.New("invalid list edit")
+
+// The operations that can be applied to a list's elements.
+const (
+	InsertListElement = "insert"
+	RemoveListElement = "remove"
+	MoveListElement   = "move"
+	NoteListElement   = "note"
+)
+
+// ListOperation changes a list's elements. Placements count from 1 and
+// refer to the list as it is when the operation is applied. Insert puts
+// Element at Placement, remove removes the element at Placement, move
+// moves the element at Placement so that it ends up at To, and note
+// replaces the note on the element at Placement with Element.Note.
+type ListOperation struct {
+	Op        string      `json:"op"`
+	Placement int         `json:"placement"`
+	To        int         `json:"to,omitempty"`
+	Element   ListElement `json:"element"`
+}
+
+// ApplyListOperations applies the operations to elements in order and
+// returns the resulting elements. elements is not modified.
+func ApplyListOperations(elements []ListElement, ops []ListOperation) ([]ListElement, error) {
+	result := append([]ListElement{}, elements...)
+	for i, op := range ops {
+		if utf8.RuneCountInString(op.Element.Note) > MaxListElementNoteLength {
+			return nil, fmt.Errorf("%w: operation %d has a note longer than %d characters", ErrInvalidListEdit, i+1, MaxListElementNoteLength)
+		}
+
+		switch op.Op {
+		case InsertListElement:
+			if op.Placement < 1 || op.Placement > len(result)+1 {
+				return nil, fmt.Errorf("%w: operation %d inserts at placement %d of %d", ErrInvalidListEdit, i+1, op.Placement, len(result))
+			}
+			result = append(result[:op.Placement-1], append([]ListElement{op.Element}, result[op.Placement-1:]...)...)
+		case RemoveListElement:
+			if op.Placement < 1 || op.Placement > len(result) {
+				return nil, fmt.Errorf("%w: operation %d removes placement %d of %d", ErrInvalidListEdit, i+1, op.Placement, len(result))
+			}
+			result = append(result[:op.Placement-1], result[op.Placement:]...)
+		case MoveListElement:
+			if op.Placement < 1 || op.Placement > len(result) || op.To < 1 || op.To > len(result) {
+				return nil, fmt.Errorf("%w: operation %d moves placement %d to %d of %d", ErrInvalidListEdit, i+1, op.Placement, op.To, len(result))
+			}
+			element := result[op.Placement-1]
+			result = append(result[:op.Placement-1], result[op.Placement:]...)
+			result = append(result[:op.To-1], append([]ListElement{element}, result[op.To-1:]...)...)
+		case NoteListElement:
+			if op.Placement < 1 || op.Placement > len(result) {
+				return nil, fmt.Errorf("%w: operation %d changes the note on placement %d of %d", ErrInvalidListEdit, i+1, op.Placement, len(result))
+			}
+			result[op.Placement-1].Note = op.Element.Note
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidListEdit, i+1, op.Op)
+		}
+	}
+
+	if len(result) < MinListElements || len(result) > MaxListElements {
+		return nil, fmt.Errorf("%w: lists must have between %d and %d elements", ErrInvalidListEdit, MinListElements, MaxListElements)
+	}
+
+	return result, nil
+}
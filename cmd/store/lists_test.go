@@ -0,0 +1,194 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// elements returns list elements whose entity IDs are the given names.
+func elements(names ...string) []ListElement {
+	result := []ListElement{}
+	for _, name := range names {
+		result = append(result, ListElement{EntityID: name, Name: name})
+	}
+	return result
+}
+
+// entityIDs returns the entity ID of each element, in order.
+func entityIDs(elements []ListElement) []string {
+	ids := []string{}
+	for _, element := range elements {
+		ids = append(ids, element.EntityID)
+	}
+	return ids
+}
+
+func TestApplyListOperations(t *testing.T) {
+	full := []string{}
+	for i := 0; i < MaxListElements; i++ {
+		full = append(full, "e"+strconv.Itoa(i))
+	}
+
+	tests := []struct {
+		name     string
+		elements []string
+		ops      []ListOperation
+		// want is the resulting entity IDs, or nil if the edit should fail.
+		want []string
+	}{
+		{
+			name:     "insert at the start",
+			elements: []string{"a", "b"},
+			ops:      []ListOperation{{Op: InsertListElement, Placement: 1, Element: ListElement{EntityID: "x"}}},
+			want:     []string{"x", "a", "b"},
+		},
+		{
+			name:     "insert at the end",
+			elements: []string{"a", "b"},
+			ops:      []ListOperation{{Op: InsertListElement, Placement: 3, Element: ListElement{EntityID: "x"}}},
+			want:     []string{"a", "b", "x"},
+		},
+		{
+			name:     "insert past the end",
+			elements: []string{"a", "b"},
+			ops:      []ListOperation{{Op: InsertListElement, Placement: 4, Element: ListElement{EntityID: "x"}}},
+		},
+		{
+			name:     "insert before the start",
+			elements: []string{"a", "b"},
+			ops:      []ListOperation{{Op: InsertListElement, Placement: 0, Element: ListElement{EntityID: "x"}}},
+		},
+		{
+			name:     "move to the same placement",
+			elements: []string{"a", "b", "c"},
+			ops:      []ListOperation{{Op: MoveListElement, Placement: 2, To: 2}},
+			want:     []string{"a", "b", "c"},
+		},
+		{
+			name:     "move forwards",
+			elements: []string{"a", "b", "c"},
+			ops:      []ListOperation{{Op: MoveListElement, Placement: 1, To: 3}},
+			want:     []string{"b", "c", "a"},
+		},
+		{
+			name:     "move backwards",
+			elements: []string{"a", "b", "c"},
+			ops:      []ListOperation{{Op: MoveListElement, Placement: 3, To: 1}},
+			want:     []string{"c", "a", "b"},
+		},
+		{
+			name:     "move past the end",
+			elements: []string{"a", "b", "c"},
+			ops:      []ListOperation{{Op: MoveListElement, Placement: 1, To: 4}},
+		},
+		{
+			name:     "move from past the end",
+			elements: []string{"a", "b", "c"},
+			ops:      []ListOperation{{Op: MoveListElement, Placement: 4, To: 1}},
+		},
+		{
+			name:     "remove down to the minimum",
+			elements: []string{"a", "b", "c"},
+			ops: []ListOperation{
+				{Op: RemoveListElement, Placement: 1},
+				{Op: RemoveListElement, Placement: 2},
+			},
+			want: []string{"b"},
+		},
+		{
+			name:     "remove below the minimum",
+			elements: []string{"a", "b"},
+			ops: []ListOperation{
+				{Op: RemoveListElement, Placement: 1},
+				{Op: RemoveListElement, Placement: 1},
+			},
+		},
+		{
+			name:     "remove past the end",
+			elements: []string{"a", "b"},
+			ops:      []ListOperation{{Op: RemoveListElement, Placement: 3}},
+		},
+		{
+			name:     "grow to the maximum",
+			elements: full[1:],
+			ops:      []ListOperation{{Op: InsertListElement, Placement: 1, Element: ListElement{EntityID: "e0"}}},
+			want:     full,
+		},
+		{
+			name:     "grow past the maximum",
+			elements: full,
+			ops:      []ListOperation{{Op: InsertListElement, Placement: 1, Element: ListElement{EntityID: "x"}}},
+		},
+		{
+			name:     "shrink back within the maximum",
+			elements: full,
+			ops: []ListOperation{
+				{Op: InsertListElement, Placement: 1, Element: ListElement{EntityID: "x"}},
+				{Op: RemoveListElement, Placement: 1},
+			},
+			want: full,
+		},
+		{
+			name:     "note of the longest length",
+			elements: []string{"a"},
+			ops:      []ListOperation{{Op: NoteListElement, Placement: 1, Element: ListElement{Note: strings.Repeat("é", MaxListElementNoteLength)}}},
+			want:     []string{"a"},
+		},
+		{
+			name:     "note that is too long",
+			elements: []string{"a"},
+			ops:      []ListOperation{{Op: NoteListElement, Placement: 1, Element: ListElement{Note: strings.Repeat("a", MaxListElementNoteLength+1)}}},
+		},
+		{
+			name:     "inserted element with a note that is too long",
+			elements: []string{"a"},
+			ops:      []ListOperation{{Op: InsertListElement, Placement: 1, Element: ListElement{EntityID: "x", Note: strings.Repeat("a", MaxListElementNoteLength+1)}}},
+		},
+		{
+			name:     "unknown op",
+			elements: []string{"a"},
+			ops:      []ListOperation{{Op: "replace", Placement: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := elements(test.elements...)
+			got, err := ApplyListOperations(original, test.ops)
+
+			if test.want == nil {
+				if !errors.Is(err, ErrInvalidListEdit) {
+					t.Fatalf("expected ErrInvalidListEdit, got %v with %v", err, entityIDs(got))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ids := entityIDs(got); !slices.Equal(ids, test.want) {
+				t.Errorf("expected %v, got %v", test.want, ids)
+			}
+			if ids := entityIDs(original); !slices.Equal(ids, test.elements) {
+				t.Errorf("expected the original elements to be left as %v, got %v", test.elements, ids)
+			}
+		})
+	}
+}
+
+func TestApplyListOperationsSetsNotes(t *testing.T) {
+	got, err := ApplyListOperations(elements("a", "b"), []ListOperation{
+		{Op: NoteListElement, Placement: 2, Element: ListElement{Note: "the best one"}},
+		{Op: MoveListElement, Placement: 2, To: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got[0].Note != "the best one" || got[1].Note != "" {
+		t.Errorf("expected the note to move with its element, got %+v", got)
+	}
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+)
+
+func (s *Store) Block(_ context.Context, blockerID string, blockedID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[blockedID]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.blocks[block{blockerID, blockedID}] = true
+	delete(s.follows, relation{blockerID, blockedID})
+	delete(s.follows, relation{blockedID, blockerID})
+	delete(s.followRequests, relation{blockerID, blockedID})
+	delete(s.followRequests, relation{blockedID, blockerID})
+
+	return nil
+}
+
+func (s *Store) Unblock(_ context.Context, blockerID string, blockedID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blocks, block{blockerID, blockedID})
+	return nil
+}
+
+func (s *Store) IsBlocked(_ context.Context, userID string, otherID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.blocks[block{userID, otherID}] || s.blocks[block{otherID, userID}], nil
+}
+
+func (s *Store) Mute(_ context.Context, muterID string, mutedID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[mutedID]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.mutes[mute{muterID, mutedID}] = true
+	return nil
+}
+
+func (s *Store) Unmute(_ context.Context, muterID string, mutedID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.mutes, mute{muterID, mutedID})
+	return nil
+}
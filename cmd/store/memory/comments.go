@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sort"
+	"strconv"
+)
+
+func (s *Store) CreateReviewComment(_ context.Context, reviewID int, c store.Comment) (store.CommentBag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reviews[reviewID]; !ok {
+		return store.CommentBag{}, store.ErrNotFound
+	}
+
+	return createComment(s, s.reviewComments, reviewID, c)
+}
+
+func (s *Store) ReviewCommentOwners(_ context.Context, id int) (string, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.reviewComments[id]
+	if !ok {
+		return "", "", store.ErrNotFound
+	}
+
+	return comment.UserID, s.reviews[comment.postID].UserID, nil
+}
+
+func (s *Store) DeleteReviewComment(_ context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleteComment(s.reviewComments, id)
+	return nil
+}
+
+func (s *Store) ReviewComments(_ context.Context, reviewID int, page store.Page) ([]store.CommentBag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return pageComments(s, s.reviewComments, reviewID, page)
+}
+
+func (s *Store) ReviewCommentCounts(_ context.Context, reviewIDs []int) (map[int]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return commentCounts(s.reviewComments, reviewIDs), nil
+}
+
+func (s *Store) CreateListComment(_ context.Context, listID string, c store.Comment) (store.CommentBag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lists[listID]; !ok {
+		return store.CommentBag{}, store.ErrNotFound
+	}
+
+	return createComment(s, s.listComments, listID, c)
+}
+
+func (s *Store) ListCommentOwners(_ context.Context, id int) (string, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.listComments[id]
+	if !ok {
+		return "", "", store.ErrNotFound
+	}
+
+	return comment.UserID, s.lists[comment.postID].UserID, nil
+}
+
+func (s *Store) DeleteListComment(_ context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleteComment(s.listComments, id)
+	return nil
+}
+
+func (s *Store) ListComments(_ context.Context, listID string, page store.Page) ([]store.CommentBag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return pageComments(s, s.listComments, listID, page)
+}
+
+func (s *Store) ListCommentCounts(_ context.Context, listIDs []string) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return commentCounts(s.listComments, listIDs), nil
+}
+
+// createComment adds c to comments. The caller must hold the write lock.
+func createComment[T comparable](s *Store, comments map[int]*comment[T], postID T, c store.Comment) (store.CommentBag, error) {
+	if c.ParentID != 0 {
+		// Only top-level comments on the same post can be replied to
+		parent, ok := comments[c.ParentID]
+		if !ok || parent.postID != postID || parent.ParentID != 0 {
+			return store.CommentBag{}, store.ErrInvalidParent
+		}
+	}
+
+	id := s.nextCommentID
+	s.nextCommentID++
+	comments[id] = &comment[T]{id: id, postID: postID, Comment: c}
+
+	return s.commentBag(id, c), nil
+}
+
+// deleteComment removes the comment and its replies. The caller must hold
+// the write lock.
+func deleteComment[T comparable](comments map[int]*comment[T], id int) {
+	for replyID, reply := range comments {
+		if reply.ParentID == id {
+			delete(comments, replyID)
+		}
+	}
+
+	delete(comments, id)
+}
+
+// pageComments returns a page of the top-level comments on the post,
+// oldest first, with their replies. The caller must hold the read lock.
+func pageComments[T comparable](s *Store, comments map[int]*comment[T], postID T, page store.Page) ([]store.CommentBag, error) {
+	var after store.CommentBag
+	if page.After != nil {
+		id, err := strconv.Atoi(page.After.ID)
+		if err != nil {
+			return nil, store.ErrInvalidCursor
+		}
+		after = store.CommentBag{ID: id, CreatedOn: page.After.CreatedOn}
+	}
+
+	topLevel := []store.CommentBag{}
+	replies := map[int][]store.CommentBag{}
+	for _, comment := range comments {
+		if comment.postID != postID {
+			continue
+		}
+
+		bag := s.commentBag(comment.id, comment.Comment)
+		if comment.ParentID != 0 {
+			replies[comment.ParentID] = append(replies[comment.ParentID], bag)
+		} else if page.After == nil || commentPrecedes(after, bag) {
+			topLevel = append(topLevel, bag)
+		}
+	}
+
+	sortComments(topLevel)
+	topLevel = topLevel[:min(len(topLevel), page.Limit)]
+	for i := range topLevel {
+		topLevel[i].Replies = replies[topLevel[i].ID]
+		sortComments(topLevel[i].Replies)
+	}
+
+	return topLevel, nil
+}
+
+func commentCounts[T comparable](comments map[int]*comment[T], postIDs []T) map[T]int {
+	wanted := map[T]bool{}
+	for _, postID := range postIDs {
+		wanted[postID] = true
+	}
+
+	counts := map[T]int{}
+	for _, comment := range comments {
+		if wanted[comment.postID] {
+			counts[comment.postID]++
+		}
+	}
+
+	return counts
+}
+
+// commentPrecedes reports whether a was posted before b.
+func commentPrecedes(a store.CommentBag, b store.CommentBag) bool {
+	if !a.CreatedOn.Equal(b.CreatedOn) {
+		return a.CreatedOn.Before(b.CreatedOn)
+	}
+	return a.ID < b.ID
+}
+
+func sortComments(comments []store.CommentBag) {
+	sort.Slice(comments, func(i, j int) bool {
+		return commentPrecedes(comments[i], comments[j])
+	})
+}
+
+// commentBag returns the comment along with its author. The caller must
+// hold the read lock.
+func (s *Store) commentBag(id int, c store.Comment) store.CommentBag {
+	return store.CommentBag{
+		ID:        id,
+		ParentID:  c.ParentID,
+		Author:    s.condensedUser(c.UserID),
+		Body:      c.Body,
+		CreatedOn: c.CreatedOn,
+	}
+}
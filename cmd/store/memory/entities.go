@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sort"
+)
+
+func (s *Store) Entity(_ context.Context, entityID string, entityType int, viewerID string) (store.Entity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entity := store.Entity{
+		EntityID:          entityID,
+		Type:              entityType,
+		ScoreDistribution: map[int]int{},
+	}
+
+	var latest *review
+	total := 0
+	for _, review := range s.reviews {
+		if review.EntityID != entityID || review.Type != entityType || review.Visibility != store.VisibilityPublic {
+			continue
+		}
+		if !s.authorVisible(viewerID, review.UserID) {
+			continue
+		}
+
+		entity.ScoreDistribution[review.Score]++
+		entity.NumReviews++
+		total += review.Score
+		if latest == nil || review.CreatedOn.After(latest.CreatedOn) {
+			latest = review
+		}
+	}
+
+	if latest != nil {
+		entity.AverageScore = float64(total) / float64(entity.NumReviews)
+		entity.Title = latest.Title
+		entity.Subtitle = latest.Subtitle
+		entity.ImageSource = latest.ImageSource
+		return entity, nil
+	}
+
+	// Nobody has reviewed it, so fall back to how it appears in lists
+	lists := s.entityLists(entityID, entityType, viewerID)
+	if len(lists) == 0 {
+		return store.Entity{}, store.ErrNotFound
+	}
+	for _, element := range lists[0].ListElements {
+		if element.EntityID == entityID {
+			entity.Title = element.Name
+			entity.ImageSource = element.ImageSrc
+			break
+		}
+	}
+
+	return entity, nil
+}
+
+func (s *Store) EntityReviews(_ context.Context, entityID string, entityType int, viewerID string, limit int) ([]store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reviews := []*review{}
+	for _, review := range s.reviews {
+		if review.EntityID == entityID && review.Type == entityType && review.Visibility == store.VisibilityPublic && s.authorVisible(viewerID, review.UserID) {
+			reviews = append(reviews, review)
+		}
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		iFollowed := s.follows[relation{viewerID, reviews[i].UserID}]
+		jFollowed := s.follows[relation{viewerID, reviews[j].UserID}]
+		if iFollowed != jFollowed {
+			return iFollowed
+		}
+		if !reviews[i].CreatedOn.Equal(reviews[j].CreatedOn) {
+			return reviews[i].CreatedOn.After(reviews[j].CreatedOn)
+		}
+		return reviews[i].id > reviews[j].id
+	})
+
+	posts := []store.Post{}
+	for _, review := range reviews[:min(len(reviews), limit)] {
+		posts = append(posts, s.reviewPost(review))
+	}
+
+	return posts, nil
+}
+
+func (s *Store) EntityLists(_ context.Context, entityID string, entityType int, viewerID string, limit int) ([]store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lists := s.entityLists(entityID, entityType, viewerID)
+
+	posts := []store.Post{}
+	for _, list := range lists[:min(len(lists), limit)] {
+		posts = append(posts, s.listPost(list))
+	}
+
+	return posts, nil
+}
+
+// entityLists returns the public lists that include the entity and that
+// viewerID can see, newest first. The caller must hold the read lock.
+func (s *Store) entityLists(entityID string, entityType int, viewerID string) []*list {
+	lists := []*list{}
+	for _, list := range s.lists {
+		if list.Type != entityType || list.Visibility != store.VisibilityPublic {
+			continue
+		}
+		if !s.authorVisible(viewerID, list.UserID) {
+			continue
+		}
+
+		for _, element := range list.ListElements {
+			if element.EntityID == entityID {
+				lists = append(lists, list)
+				break
+			}
+		}
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		if !lists[i].CreatedOn.Equal(lists[j].CreatedOn) {
+			return lists[i].CreatedOn.After(lists[j].CreatedOn)
+		}
+		return lists[i].id > lists[j].id
+	})
+
+	return lists
+}
+
+// authorVisible reports whether viewerID can see the posts of authorID,
+// which they can't if authorID is a private account they don't follow.
+// The caller must hold the read lock.
+func (s *Store) authorVisible(viewerID string, authorID string) bool {
+	return !s.users[authorID].IsPrivate || viewerID == authorID || s.follows[relation{viewerID, authorID}]
+}
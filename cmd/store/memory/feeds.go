@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sort"
+)
+
+func (s *Store) Timeline(_ context.Context, userID string, page store.Page) ([]store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.posts(func(authorID string, visibility string) bool {
+		if visibility == store.VisibilityPrivate {
+			return false
+		}
+		if s.blocks[block{userID, authorID}] || s.mutes[mute{userID, authorID}] {
+			return false
+		}
+		return authorID == userID || s.follows[relation{userID, authorID}]
+	})
+
+	return paginate(posts, page), nil
+}
+
+func (s *Store) TimelineAudience(_ context.Context, authorID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userIDs := []string{}
+	for rel := range s.follows {
+		if rel.followeeID == authorID && !s.mutes[mute{rel.followerID, authorID}] {
+			userIDs = append(userIDs, rel.followerID)
+		}
+	}
+
+	return userIDs, nil
+}
+
+func (s *Store) Activity(_ context.Context, userID string, asFollower bool, page store.Page) ([]store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.posts(func(authorID string, visibility string) bool {
+		if visibility == store.VisibilityPrivate || (visibility == store.VisibilityFollowers && !asFollower) {
+			return false
+		}
+		return authorID == userID
+	})
+
+	return paginate(posts, page), nil
+}
+
+func (s *Store) Drafts(_ context.Context, userID string, page store.Page) ([]store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.posts(func(authorID string, visibility string) bool {
+		return authorID == userID && visibility == store.VisibilityPrivate
+	})
+
+	return paginate(posts, page), nil
+}
+
+// paginate sorts posts the same way the Postgres store does and returns
+// the requested page.
+func paginate(posts []store.Post, page store.Page) []store.Post {
+	sort.Slice(posts, func(i, j int) bool {
+		return precedes(store.CursorFor(posts[i]), store.CursorFor(posts[j]))
+	})
+
+	start := 0
+	if page.After != nil {
+		for start < len(posts) && !precedes(*page.After, store.CursorFor(posts[start])) {
+			start++
+		}
+	}
+	end := min(len(posts), start+page.Limit)
+
+	return posts[start:end]
+}
+
+// precedes reports whether a comes before b in a feed.
+func precedes(a store.Cursor, b store.Cursor) bool {
+	if !a.CreatedOn.Equal(b.CreatedOn) {
+		return a.CreatedOn.After(b.CreatedOn)
+	}
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	return a.ID > b.ID
+}
+
+// posts returns every review and list whose author and visibility match
+// include. The caller must hold the read lock.
+func (s *Store) posts(include func(authorID string, visibility string) bool) []store.Post {
+	posts := []store.Post{}
+	for _, review := range s.reviews {
+		if include(review.UserID, review.Visibility) {
+			posts = append(posts, s.reviewPost(review))
+		}
+	}
+
+	for _, list := range s.lists {
+		if include(list.UserID, list.Visibility) {
+			posts = append(posts, s.listPost(list))
+		}
+	}
+
+	return posts
+}
+
+// reviewPost returns the review as a post. The caller must hold the read
+// lock.
+func (s *Store) reviewPost(review *review) store.Post {
+	return store.Post{
+		Type:      store.ReviewType,
+		Author:    s.condensedUser(review.UserID),
+		Timestamp: review.CreatedOn,
+		Review: store.ReviewBag{
+			ID:          review.id,
+			EntityID:    review.EntityID,
+			Type:        review.Type,
+			Title:       review.Title,
+			Subtitle:    review.Subtitle,
+			Colour:      review.Colour,
+			ImageSource: review.ImageSource,
+			Score:       review.Score,
+			Body:        review.Body,
+			IsEdited:    review.editedOn != nil,
+			EditedOn:    review.editedOn,
+			Visibility:  review.Visibility,
+		},
+	}
+}
+
+// listPost returns the list as a post, without its elements. The caller
+// must hold the read lock.
+func (s *Store) listPost(list *list) store.Post {
+	return store.Post{
+		Type:      store.ListType,
+		Author:    s.condensedUser(list.UserID),
+		Timestamp: list.CreatedOn,
+		List: store.ListBag{
+			ID:         list.id,
+			Type:       list.Type,
+			Title:      list.Title,
+			Colour:     list.Colour,
+			Ranked:     list.Ranked,
+			UpdatedOn:  list.updatedOn,
+			ForkedFrom: list.ForkedFrom,
+			Visibility: list.Visibility,
+		},
+	}
+}
@@ -0,0 +1,314 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateList(_ context.Context, l store.List) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[l.UserID]; !ok {
+		return "", store.ErrNotFound
+	}
+
+	id := uuid.NewString()
+	l.ListElements = withAddedBy(l.ListElements, l.UserID)
+	s.lists[id] = &list{id: id, List: l}
+
+	return id, nil
+}
+
+func (s *Store) List(_ context.Context, id string) (store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, ok := s.lists[id]
+	if !ok {
+		return store.Post{}, store.ErrNotFound
+	}
+
+	return s.listPost(list), nil
+}
+
+func (s *Store) ListOwner(_ context.Context, id string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, ok := s.lists[id]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+
+	return list.UserID, nil
+}
+
+func (s *Store) DeleteList(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteList(id)
+	return nil
+}
+
+// deleteList removes the list, its likes, comments and collaborators and
+// the notifications about it. The caller must hold the write lock.
+func (s *Store) deleteList(id string) {
+	for notificationID, notification := range s.notifications {
+		if notification.listID == id {
+			delete(s.notifications, notificationID)
+		}
+	}
+	for _, fork := range s.lists {
+		if fork.ForkedFrom == id {
+			fork.ForkedFrom = ""
+		}
+	}
+	for collaborator := range s.collaborators {
+		if collaborator.listID == id {
+			delete(s.collaborators, collaborator)
+		}
+	}
+	for like := range s.listLikes {
+		if like.listID == id {
+			delete(s.listLikes, like)
+		}
+	}
+	for commentID, comment := range s.listComments {
+		if comment.postID == id {
+			delete(s.listComments, commentID)
+		}
+	}
+
+	delete(s.lists, id)
+}
+
+func (s *Store) UpdateList(_ context.Context, id string, update store.ListUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, ok := s.lists[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	list.Title = update.Title
+	list.Colour = update.Colour
+	if update.Visibility != "" {
+		list.Visibility = update.Visibility
+	}
+	updatedOn := update.UpdatedOn
+	list.updatedOn = &updatedOn
+
+	return nil
+}
+
+func (s *Store) UpdateListElements(_ context.Context, id string, ops []store.ListOperation, updatedOn time.Time) ([]store.ListElement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, ok := s.lists[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	elements, err := store.ApplyListOperations(list.ListElements, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	list.ListElements = withAddedBy(elements, list.UserID)
+	list.updatedOn = &updatedOn
+
+	return s.listElements(list), nil
+}
+
+func (s *Store) ListElements(_ context.Context, listIDs []string) (map[string][]store.ListElement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	listElements := map[string][]store.ListElement{}
+	for _, listID := range listIDs {
+		if list, ok := s.lists[listID]; ok {
+			listElements[listID] = s.listElements(list)
+		}
+	}
+
+	return listElements, nil
+}
+
+// listElements returns a copy of the list's elements with the details of
+// who added each one. The caller must hold the read lock.
+func (s *Store) listElements(list *list) []store.ListElement {
+	elements := append([]store.ListElement{}, list.ListElements...)
+	for i := range elements {
+		elements[i].AddedBy = s.condensedUser(elements[i].AddedBy.ID)
+	}
+
+	return elements
+}
+
+// withAddedBy returns a copy of elements in which those that don't say who
+// added them are attributed to ownerID.
+func withAddedBy(elements []store.ListElement, ownerID string) []store.ListElement {
+	elements = append([]store.ListElement{}, elements...)
+	for i := range elements {
+		if elements[i].AddedBy.ID == "" {
+			elements[i].AddedBy = store.UserCondensed{ID: ownerID}
+		}
+	}
+
+	return elements
+}
+
+func (s *Store) ListForkCounts(_ context.Context, listIDs []string) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, listID := range listIDs {
+		wanted[listID] = true
+	}
+
+	counts := map[string]int{}
+	for _, list := range s.lists {
+		if !wanted[list.ForkedFrom] || list.Visibility != store.VisibilityPublic || s.users[list.UserID].IsPrivate {
+			continue
+		}
+		counts[list.ForkedFrom]++
+	}
+
+	return counts, nil
+}
+
+func (s *Store) AddListCollaborator(_ context.Context, listID string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lists[listID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	collaborator := listCollaborator{listID, userID}
+	if _, ok := s.collaborators[collaborator]; !ok {
+		s.collaborators[collaborator] = time.Now()
+	}
+
+	return nil
+}
+
+func (s *Store) RemoveListCollaborator(_ context.Context, listID string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.collaborators, listCollaborator{listID, userID})
+	return nil
+}
+
+func (s *Store) IsListCollaborator(_ context.Context, listID string, userID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.collaborators[listCollaborator{listID, userID}]
+	return ok, nil
+}
+
+func (s *Store) ListCollaborators(_ context.Context, listID string) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collaborators := []listCollaborator{}
+	for collaborator := range s.collaborators {
+		if collaborator.listID == listID {
+			collaborators = append(collaborators, collaborator)
+		}
+	}
+
+	sort.Slice(collaborators, func(i, j int) bool {
+		return s.collaborators[collaborators[i]].Before(s.collaborators[collaborators[j]])
+	})
+
+	users := []store.UserCondensed{}
+	for _, collaborator := range collaborators {
+		users = append(users, s.condensedUser(collaborator.userID))
+	}
+
+	return users, nil
+}
+
+func (s *Store) HasLikedList(_ context.Context, userID string, listID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listLikes[listLike{listID, userID}], nil
+}
+
+func (s *Store) LikeList(_ context.Context, userID string, listID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lists[listID]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.listLikes[listLike{listID, userID}] = true
+	return nil
+}
+
+func (s *Store) UnlikeList(_ context.Context, userID string, listID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listLikes, listLike{listID, userID})
+	return nil
+}
+
+func (s *Store) ListLikes(_ context.Context, listID string) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []store.UserCondensed{}
+	for like := range s.listLikes {
+		if like.listID == listID {
+			users = append(users, s.condensedUser(like.userID))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users, nil
+}
+
+func (s *Store) ListLikeSummaries(_ context.Context, viewerID string, listIDs []string) (map[string]store.LikeSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, listID := range listIDs {
+		wanted[listID] = true
+	}
+
+	summaries := map[string]store.LikeSummary{}
+	for like := range s.listLikes {
+		if !wanted[like.listID] {
+			continue
+		}
+
+		summary := summaries[like.listID]
+		summary.Count++
+		summary.IsLiked = summary.IsLiked || like.userID == viewerID
+		summaries[like.listID] = summary
+	}
+
+	return summaries, nil
+}
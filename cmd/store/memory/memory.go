@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sync"
+	"time"
+)
+
+var _ store.Store = (*Store)(nil)
+
+type relation struct {
+	followerID string
+	followeeID string
+}
+
+type block struct {
+	blockerID string
+	blockedID string
+}
+
+type mute struct {
+	muterID string
+	mutedID string
+}
+
+type reviewLike struct {
+	reviewID int
+	userID   string
+}
+
+type listLike struct {
+	listID string
+	userID string
+}
+
+type listCollaborator struct {
+	listID string
+	userID string
+}
+
+type review struct {
+	id int
+	store.Review
+	editedOn  *time.Time
+	revisions []store.ReviewRevision
+}
+
+type list struct {
+	id string
+	store.List
+	updatedOn *time.Time
+}
+
+// notification is a notification sent to userID. actors holds when each
+// of the users involved last acted.
+type notification struct {
+	id        int
+	userID    string
+	kind      string
+	reviewID  int
+	listID    string
+	isRead    bool
+	updatedOn time.Time
+	actors    map[string]time.Time
+}
+
+// comment is a comment on the review or list identified by postID.
+type comment[T comparable] struct {
+	id     int
+	postID T
+	store.Comment
+}
+
+// Store is an in-memory implementation of store.Store. It is safe for
+// concurrent use and is intended for tests and local development.
+type Store struct {
+	mu sync.RWMutex
+
+	users      map[string]store.User
+	musicNotes map[string][]store.MusicNote
+	follows    map[relation]bool
+	// followRequests holds when each pending follow request was made
+	followRequests map[relation]time.Time
+	blocks         map[block]bool
+	mutes          map[mute]bool
+	reviews        map[int]*review
+	lists          map[string]*list
+	reviewLikes    map[reviewLike]bool
+	listLikes      map[listLike]bool
+	// collaborators holds when each collaborator was added to the list
+	collaborators map[listCollaborator]time.Time
+
+	reviewComments map[int]*comment[int]
+	listComments   map[int]*comment[string]
+
+	notifications map[int]*notification
+
+	nextReviewID       int
+	nextCommentID      int
+	nextNotificationID int
+}
+
+func New() *Store {
+	return &Store{
+		users:          map[string]store.User{},
+		musicNotes:     map[string][]store.MusicNote{},
+		follows:        map[relation]bool{},
+		followRequests: map[relation]time.Time{},
+		blocks:         map[block]bool{},
+		mutes:          map[mute]bool{},
+		reviews:        map[int]*review{},
+		lists:          map[string]*list{},
+		reviewLikes:    map[reviewLike]bool{},
+		listLikes:      map[listLike]bool{},
+		collaborators:  map[listCollaborator]time.Time{},
+		reviewComments: map[int]*comment[int]{},
+		listComments:   map[int]*comment[string]{},
+		notifications:  map[int]*notification{},
+
+		nextReviewID:       1,
+		nextCommentID:      1,
+		nextNotificationID: 1,
+	}
+}
+
+func (s *Store) Ping(_ context.Context) error {
+	return nil
+}
+
+func (s *Store) condensedUser(id string) store.UserCondensed {
+	user := s.users[id]
+	return store.UserCondensed{
+		ID:          user.ID,
+		Name:        user.Name,
+		ImageSource: user.ImageSource,
+	}
+}
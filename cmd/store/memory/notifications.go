@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func (s *Store) Notify(_ context.Context, n store.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[n.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	var existing *notification
+	if n.Collapses() {
+		for _, notification := range s.notifications {
+			if notification.userID == n.UserID && notification.kind == n.Kind && notification.reviewID == n.ReviewID && notification.listID == n.ListID {
+				existing = notification
+				break
+			}
+		}
+	}
+
+	if existing == nil {
+		existing = &notification{
+			id:       s.nextNotificationID,
+			userID:   n.UserID,
+			kind:     n.Kind,
+			reviewID: n.ReviewID,
+			listID:   n.ListID,
+			actors:   map[string]time.Time{},
+		}
+		s.notifications[existing.id] = existing
+		s.nextNotificationID++
+	}
+
+	existing.isRead = false
+	existing.updatedOn = n.CreatedOn
+	existing.actors[n.ActorID] = n.CreatedOn
+
+	return nil
+}
+
+func (s *Store) Notifications(_ context.Context, userID string, page store.Page) ([]store.NotificationBag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	afterID := 0
+	if page.After != nil {
+		id, err := strconv.Atoi(page.After.ID)
+		if err != nil {
+			return nil, store.ErrInvalidCursor
+		}
+		afterID = id
+	}
+
+	notifications := []*notification{}
+	for _, notification := range s.notifications {
+		if notification.userID == userID && len(notification.actors) > 0 {
+			notifications = append(notifications, notification)
+		}
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notificationPrecedes(notifications[i].updatedOn, notifications[i].id, notifications[j].updatedOn, notifications[j].id)
+	})
+
+	bags := []store.NotificationBag{}
+	for _, notification := range notifications {
+		if page.After != nil && !notificationPrecedes(page.After.CreatedOn, afterID, notification.updatedOn, notification.id) {
+			continue
+		}
+		if len(bags) == page.Limit {
+			break
+		}
+		bags = append(bags, s.notificationBag(notification))
+	}
+
+	return bags, nil
+}
+
+// notificationPrecedes reports whether the notification updated at aTime
+// with ID aID comes before the one updated at bTime with ID bID.
+func notificationPrecedes(aTime time.Time, aID int, bTime time.Time, bID int) bool {
+	if !aTime.Equal(bTime) {
+		return aTime.After(bTime)
+	}
+	return aID > bID
+}
+
+// notificationBag returns the notification as its recipient sees it. The
+// caller must hold the read lock.
+func (s *Store) notificationBag(n *notification) store.NotificationBag {
+	actorIDs := []string{}
+	for actorID := range n.actors {
+		actorIDs = append(actorIDs, actorID)
+	}
+
+	sort.Slice(actorIDs, func(i, j int) bool {
+		return n.actors[actorIDs[i]].After(n.actors[actorIDs[j]])
+	})
+
+	actors := []store.UserCondensed{}
+	for _, actorID := range actorIDs[:min(len(actorIDs), store.MaxNotificationActors)] {
+		actors = append(actors, s.condensedUser(actorID))
+	}
+
+	return store.NotificationBag{
+		ID:         n.id,
+		Kind:       n.kind,
+		Actors:     actors,
+		ActorCount: len(n.actors),
+		ReviewID:   n.reviewID,
+		ListID:     n.listID,
+		IsRead:     n.isRead,
+		UpdatedOn:  n.updatedOn,
+	}
+}
+
+func (s *Store) UnreadNotificationCount(_ context.Context, userID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, notification := range s.notifications {
+		if notification.userID == userID && !notification.isRead && len(notification.actors) > 0 {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *Store) MarkNotificationsRead(_ context.Context, userID string, ids []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := map[int]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	for _, notification := range s.notifications {
+		if notification.userID == userID && (len(ids) == 0 || wanted[notification.id]) {
+			notification.isRead = true
+		}
+	}
+
+	return nil
+}
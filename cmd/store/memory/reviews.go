@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sort"
+)
+
+func (s *Store) CreateReview(_ context.Context, r store.Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[r.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	id := s.nextReviewID
+	s.nextReviewID++
+	s.reviews[id] = &review{id: id, Review: r}
+
+	return nil
+}
+
+func (s *Store) Review(_ context.Context, id int) (store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	review, ok := s.reviews[id]
+	if !ok {
+		return store.Post{}, store.ErrNotFound
+	}
+
+	return s.reviewPost(review), nil
+}
+
+func (s *Store) ReviewOwner(_ context.Context, id int) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	review, ok := s.reviews[id]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+
+	return review.UserID, nil
+}
+
+func (s *Store) DeleteReview(_ context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteReview(id)
+	return nil
+}
+
+// deleteReview removes the review, its likes, its comments and the
+// notifications about it. The caller must hold the write lock.
+func (s *Store) deleteReview(id int) {
+	for notificationID, notification := range s.notifications {
+		if notification.reviewID == id {
+			delete(s.notifications, notificationID)
+		}
+	}
+	for like := range s.reviewLikes {
+		if like.reviewID == id {
+			delete(s.reviewLikes, like)
+		}
+	}
+	for commentID, comment := range s.reviewComments {
+		if comment.postID == id {
+			delete(s.reviewComments, commentID)
+		}
+	}
+
+	delete(s.reviews, id)
+}
+
+func (s *Store) UpdateReview(_ context.Context, id int, edit store.ReviewEdit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	review, ok := s.reviews[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	previous := store.ReviewRevision{
+		Title:      review.Title,
+		Subtitle:   review.Subtitle,
+		Score:      review.Score,
+		Body:       review.Body,
+		ReplacedOn: edit.EditedOn,
+	}
+	if edit.ChangesContents(previous) {
+		review.revisions = append(review.revisions, previous)
+
+		review.Title = edit.Title
+		review.Subtitle = edit.Subtitle
+		review.Score = edit.Score
+		review.Body = edit.Body
+		editedOn := edit.EditedOn
+		review.editedOn = &editedOn
+	}
+
+	if edit.Visibility != "" {
+		review.Visibility = edit.Visibility
+	}
+
+	return nil
+}
+
+func (s *Store) ReviewRevisions(_ context.Context, id int) ([]store.ReviewRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := []store.ReviewRevision{}
+	if review, ok := s.reviews[id]; ok {
+		for i := len(review.revisions) - 1; i >= 0; i-- {
+			revisions = append(revisions, review.revisions[i])
+		}
+	}
+
+	return revisions, nil
+}
+
+func (s *Store) HasLikedReview(_ context.Context, userID string, reviewID int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.reviewLikes[reviewLike{reviewID, userID}], nil
+}
+
+func (s *Store) LikeReview(_ context.Context, userID string, reviewID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reviews[reviewID]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.reviewLikes[reviewLike{reviewID, userID}] = true
+	return nil
+}
+
+func (s *Store) UnlikeReview(_ context.Context, userID string, reviewID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reviewLikes, reviewLike{reviewID, userID})
+	return nil
+}
+
+func (s *Store) ReviewLikes(_ context.Context, reviewID int) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []store.UserCondensed{}
+	for like := range s.reviewLikes {
+		if like.reviewID == reviewID {
+			users = append(users, s.condensedUser(like.userID))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users, nil
+}
+
+func (s *Store) ReviewLikeSummaries(_ context.Context, viewerID string, reviewIDs []int) (map[int]store.LikeSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := map[int]bool{}
+	for _, reviewID := range reviewIDs {
+		wanted[reviewID] = true
+	}
+
+	summaries := map[int]store.LikeSummary{}
+	for like := range s.reviewLikes {
+		if !wanted[like.reviewID] {
+			continue
+		}
+
+		summary := summaries[like.reviewID]
+		summary.Count++
+		summary.IsLiked = summary.IsLiked || like.userID == viewerID
+		summaries[like.reviewID] = summary
+	}
+
+	return summaries, nil
+}
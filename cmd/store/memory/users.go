@@ -0,0 +1,385 @@
+package memory
+
+import (
+	"context"
+	"on-the-record-api/cmd/store"
+	"sort"
+	"strings"
+	"time"
+)
+
+func (s *Store) GetUser(_ context.Context, id string) (store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return store.User{}, store.ErrNotFound
+	}
+
+	for rel := range s.follows {
+		if rel.followeeID == id {
+			user.Followers++
+		}
+		if rel.followerID == id {
+			user.Following++
+		}
+	}
+	for _, review := range s.reviews {
+		if review.UserID == id && review.Visibility != store.VisibilityPrivate {
+			user.Reviews++
+		}
+	}
+	for _, list := range s.lists {
+		if list.UserID == id && list.Visibility != store.VisibilityPrivate {
+			user.Lists++
+		}
+	}
+
+	return user, nil
+}
+
+func (s *Store) GetUsers(_ context.Context, ids []string) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []store.UserCondensed{}
+	for _, id := range ids {
+		if _, ok := s.users[id]; ok {
+			users = append(users, s.condensedUser(id))
+		}
+	}
+
+	return users, nil
+}
+
+func (s *Store) SearchUsers(_ context.Context, prefix string, limit int) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []store.UserCondensed{}
+	for id, user := range s.users {
+		if strings.HasPrefix(strings.ToLower(user.Name), strings.ToLower(prefix)) {
+			users = append(users, s.condensedUser(id))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+
+	return users[:min(len(users), limit)], nil
+}
+
+func (s *Store) UserExists(_ context.Context, id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.users[id]
+	return ok, nil
+}
+
+func (s *Store) IsPrivate(_ context.Context, id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return false, store.ErrNotFound
+	}
+
+	return user.IsPrivate, nil
+}
+
+func (s *Store) CreateUser(_ context.Context, user store.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	musicNotes := user.MusicNotes
+	user.MusicNotes = nil
+	s.users[user.ID] = user
+	s.musicNotes[user.ID] = append([]store.MusicNote{}, musicNotes...)
+
+	return nil
+}
+
+func (s *Store) UpdateUser(_ context.Context, user store.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.users[user.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	existing.Name = user.Name
+	existing.Colour = user.Colour
+	existing.ImageSource = user.ImageSource
+	existing.IsPrivate = user.IsPrivate
+	s.users[user.ID] = existing
+	s.musicNotes[user.ID] = append([]store.MusicNote{}, user.MusicNotes...)
+
+	if !user.IsPrivate {
+		for rel := range s.followRequests {
+			if rel.followeeID == user.ID {
+				delete(s.followRequests, rel)
+				s.follows[rel] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteUser(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for reviewID, review := range s.reviews {
+		if review.UserID == id {
+			s.deleteReview(reviewID)
+		}
+	}
+	for listID, list := range s.lists {
+		if list.UserID == id {
+			s.deleteList(listID)
+		}
+	}
+	// Elements the user added to other people's lists stay, credited to the
+	// list's owner
+	for _, list := range s.lists {
+		for i := range list.ListElements {
+			if list.ListElements[i].AddedBy.ID == id {
+				list.ListElements[i].AddedBy = store.UserCondensed{ID: list.UserID}
+			}
+		}
+	}
+	for collaborator := range s.collaborators {
+		if collaborator.userID == id {
+			delete(s.collaborators, collaborator)
+		}
+	}
+	for rel := range s.follows {
+		if rel.followerID == id || rel.followeeID == id {
+			delete(s.follows, rel)
+		}
+	}
+	for rel := range s.followRequests {
+		if rel.followerID == id || rel.followeeID == id {
+			delete(s.followRequests, rel)
+		}
+	}
+	for b := range s.blocks {
+		if b.blockerID == id || b.blockedID == id {
+			delete(s.blocks, b)
+		}
+	}
+	for m := range s.mutes {
+		if m.muterID == id || m.mutedID == id {
+			delete(s.mutes, m)
+		}
+	}
+	for like := range s.reviewLikes {
+		if like.userID == id {
+			delete(s.reviewLikes, like)
+		}
+	}
+	for like := range s.listLikes {
+		if like.userID == id {
+			delete(s.listLikes, like)
+		}
+	}
+	for commentID, comment := range s.reviewComments {
+		if comment.UserID == id {
+			deleteComment(s.reviewComments, commentID)
+		}
+	}
+	for commentID, comment := range s.listComments {
+		if comment.UserID == id {
+			deleteComment(s.listComments, commentID)
+		}
+	}
+
+	for notificationID, notification := range s.notifications {
+		if notification.userID == id {
+			delete(s.notifications, notificationID)
+		}
+		delete(notification.actors, id)
+	}
+
+	delete(s.musicNotes, id)
+	delete(s.users, id)
+
+	return nil
+}
+
+func (s *Store) MusicNotes(_ context.Context, userID string) ([]store.MusicNote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]store.MusicNote{}, s.musicNotes[userID]...), nil
+}
+
+func (s *Store) Follow(_ context.Context, followerID string, followeeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[followeeID]; !ok {
+		return store.ErrNotFound
+	}
+
+	s.follows[relation{followerID, followeeID}] = true
+	return nil
+}
+
+func (s *Store) Unfollow(_ context.Context, followerID string, followeeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.follows, relation{followerID, followeeID})
+	delete(s.followRequests, relation{followerID, followeeID})
+	return nil
+}
+
+func (s *Store) IsFollowing(_ context.Context, followerID string, followeeID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.follows[relation{followerID, followeeID}], nil
+}
+
+func (s *Store) RequestFollow(_ context.Context, followerID string, followeeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[followeeID]; !ok {
+		return store.ErrNotFound
+	}
+
+	rel := relation{followerID, followeeID}
+	if _, ok := s.followRequests[rel]; !ok {
+		s.followRequests[rel] = time.Now()
+	}
+
+	return nil
+}
+
+func (s *Store) HasRequestedFollow(_ context.Context, followerID string, followeeID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.followRequests[relation{followerID, followeeID}]
+	return ok, nil
+}
+
+func (s *Store) FollowRequests(_ context.Context, followeeID string) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	requests := []relation{}
+	for rel := range s.followRequests {
+		if rel.followeeID == followeeID {
+			requests = append(requests, rel)
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return s.followRequests[requests[i]].Before(s.followRequests[requests[j]])
+	})
+
+	users := []store.UserCondensed{}
+	for _, rel := range requests {
+		users = append(users, s.condensedUser(rel.followerID))
+	}
+
+	return users, nil
+}
+
+func (s *Store) ApproveFollowRequest(_ context.Context, followeeID string, followerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rel := relation{followerID, followeeID}
+	if _, ok := s.followRequests[rel]; !ok {
+		return store.ErrNotFound
+	}
+
+	delete(s.followRequests, rel)
+	s.follows[rel] = true
+
+	return nil
+}
+
+func (s *Store) DenyFollowRequest(_ context.Context, followeeID string, followerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rel := relation{followerID, followeeID}
+	if _, ok := s.followRequests[rel]; !ok {
+		return store.ErrNotFound
+	}
+
+	delete(s.followRequests, rel)
+	return nil
+}
+
+func (s *Store) FollowingAmong(_ context.Context, followerID string, userIDs []string) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	following := map[string]bool{}
+	for _, userID := range userIDs {
+		if s.follows[relation{followerID, userID}] {
+			following[userID] = true
+		}
+	}
+
+	return following, nil
+}
+
+func (s *Store) Followers(_ context.Context, userID string, mutualsOnly bool, page store.Page) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := []string{}
+	for rel := range s.follows {
+		if rel.followeeID == userID && (!mutualsOnly || s.follows[relation{userID, rel.followerID}]) {
+			ids = append(ids, rel.followerID)
+		}
+	}
+
+	return s.followPage(ids, page), nil
+}
+
+func (s *Store) Following(_ context.Context, userID string, mutualsOnly bool, page store.Page) ([]store.UserCondensed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := []string{}
+	for rel := range s.follows {
+		if rel.followerID == userID && (!mutualsOnly || s.follows[relation{rel.followeeID, userID}]) {
+			ids = append(ids, rel.followeeID)
+		}
+	}
+
+	return s.followPage(ids, page), nil
+}
+
+// followPage sorts the user IDs and returns the requested page of users.
+// The caller must hold the read lock.
+func (s *Store) followPage(ids []string, page store.Page) []store.UserCondensed {
+	sort.Strings(ids)
+
+	users := []store.UserCondensed{}
+	for _, id := range ids {
+		if page.After != nil && id <= page.After.ID {
+			continue
+		}
+		if len(users) == page.Limit {
+			break
+		}
+		users = append(users, s.condensedUser(id))
+	}
+
+	return users
+}
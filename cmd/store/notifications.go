@@ -0,0 +1,51 @@
+package store
+
+import "time"
+
+// Kinds of notification.
+const (
+	NotificationReviewLike    = "review_like"
+	NotificationListLike      = "list_like"
+	NotificationFollow        = "follow"
+	NotificationFollowRequest = "follow_request"
+	NotificationReviewComment = "review_comment"
+	NotificationListComment   = "list_comment"
+)
+
+// MaxNotificationActors is how many of the users behind a collapsed
+// notification are listed with it.
+const MaxNotificationActors = 3
+
+// Notification is something that ActorID did that UserID should hear
+// about. ReviewID or ListID is set when it concerns a post.
+type Notification struct {
+	UserID    string
+	ActorID   string
+	Kind      string
+	ReviewID  int
+	ListID    string
+	CreatedOn time.Time
+}
+
+// Collapses reports whether the notification is merged into any earlier
+// one of the same kind about the same post, rather than being added
+// separately. Repeated likes are collapsed so that a popular post doesn't
+// flood its author's notifications.
+func (n Notification) Collapses() bool {
+	return n.Kind == NotificationReviewLike || n.Kind == NotificationListLike
+}
+
+// NotificationBag is a notification as its recipient sees it. Actors holds
+// up to MaxNotificationActors of the users involved, most recent first,
+// and ActorCount says how many there are in all. UpdatedOn is when the
+// latest of them acted.
+type NotificationBag struct {
+	ID         int             `json:"id"`
+	Kind       string          `json:"kind"`
+	Actors     []UserCondensed `json:"actors"`
+	ActorCount int             `json:"actorCount"`
+	ReviewID   int             `json:"reviewId,omitempty"`
+	ListID     string          `json:"listId,omitempty"`
+	IsRead     bool            `json:"isRead"`
+	UpdatedOn  time.Time       `json:"updatedOn"`
+}
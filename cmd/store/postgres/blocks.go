@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"on-the-record-api/cmd/store"
+)
+
+func (s *Store) Block(ctx context.Context, blockerID string, blockedID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1);", blockedID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+
+	query := "INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;"
+	if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"follower_relation", "follow_requests"} {
+		query = fmt.Sprintf(`DELETE FROM %s
+		WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1);`, table)
+		if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) Unblock(ctx context.Context, blockerID string, blockedID string) error {
+	query := "DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2;"
+	_, err := s.db.ExecContext(ctx, query, blockerID, blockedID)
+	return err
+}
+
+func (s *Store) IsBlocked(ctx context.Context, userID string, otherID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_blocks
+	WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1));`
+
+	var blocked bool
+	err := s.db.QueryRowContext(ctx, query, userID, otherID).Scan(&blocked)
+	return blocked, err
+}
+
+func (s *Store) Mute(ctx context.Context, muterID string, mutedID string) error {
+	exists, err := s.UserExists(ctx, mutedID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+
+	query := "INSERT INTO user_mutes (muter_id, muted_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;"
+	_, err = s.db.ExecContext(ctx, query, muterID, mutedID)
+	return err
+}
+
+func (s *Store) Unmute(ctx context.Context, muterID string, mutedID string) error {
+	query := "DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2;"
+	_, err := s.db.ExecContext(ctx, query, muterID, mutedID)
+	return err
+}
@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"on-the-record-api/cmd/store"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// commentTable describes where the comments on one kind of post are kept.
+// Its fields are constants that get interpolated into queries.
+type commentTable struct {
+	name         string
+	posts        string
+	targetColumn string
+}
+
+var (
+	reviewComments = commentTable{name: "review_comments", posts: "reviews", targetColumn: "review_id"}
+	listComments   = commentTable{name: "list_comments", posts: "lists", targetColumn: "list_id"}
+)
+
+func (s *Store) CreateReviewComment(ctx context.Context, reviewID int, comment store.Comment) (store.CommentBag, error) {
+	return s.createComment(ctx, reviewComments, reviewID, comment)
+}
+
+func (s *Store) ReviewCommentOwners(ctx context.Context, id int) (string, string, error) {
+	return s.commentOwners(ctx, reviewComments, id)
+}
+
+func (s *Store) DeleteReviewComment(ctx context.Context, id int) error {
+	return s.deleteComment(ctx, reviewComments, id)
+}
+
+func (s *Store) ReviewComments(ctx context.Context, reviewID int, page store.Page) ([]store.CommentBag, error) {
+	return s.comments(ctx, reviewComments, reviewID, page)
+}
+
+func (s *Store) ReviewCommentCounts(ctx context.Context, reviewIDs []int) (map[int]int, error) {
+	counts := map[int]int{}
+	if len(reviewIDs) == 0 {
+		return counts, nil
+	}
+
+	query := "SELECT review_id, COUNT(*) FROM review_comments WHERE review_id = ANY($1) GROUP BY review_id"
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(reviewIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewID, count int
+		if err := rows.Scan(&reviewID, &count); err != nil {
+			return nil, err
+		}
+		counts[reviewID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (s *Store) CreateListComment(ctx context.Context, listID string, comment store.Comment) (store.CommentBag, error) {
+	return s.createComment(ctx, listComments, listID, comment)
+}
+
+func (s *Store) ListCommentOwners(ctx context.Context, id int) (string, string, error) {
+	return s.commentOwners(ctx, listComments, id)
+}
+
+func (s *Store) DeleteListComment(ctx context.Context, id int) error {
+	return s.deleteComment(ctx, listComments, id)
+}
+
+func (s *Store) ListComments(ctx context.Context, listID string, page store.Page) ([]store.CommentBag, error) {
+	return s.comments(ctx, listComments, listID, page)
+}
+
+func (s *Store) ListCommentCounts(ctx context.Context, listIDs []string) (map[string]int, error) {
+	counts := map[string]int{}
+	if len(listIDs) == 0 {
+		return counts, nil
+	}
+
+	query := "SELECT list_id, COUNT(*) FROM list_comments WHERE list_id = ANY($1) GROUP BY list_id"
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(listIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var listID string
+		var count int
+		if err := rows.Scan(&listID, &count); err != nil {
+			return nil, err
+		}
+		counts[listID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (s *Store) createComment(ctx context.Context, table commentTable, targetID any, comment store.Comment) (store.CommentBag, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.CommentBag{}, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1);", table.posts)
+	if err := tx.QueryRowContext(ctx, query, targetID).Scan(&exists); err != nil {
+		return store.CommentBag{}, err
+	}
+	if !exists {
+		return store.CommentBag{}, store.ErrNotFound
+	}
+
+	var parentID sql.NullInt64
+	if comment.ParentID != 0 {
+		// Only top-level comments on the same post can be replied to
+		var grandparentID sql.NullInt64
+		query = fmt.Sprintf("SELECT parent_id FROM %s WHERE id = $1 AND %s = $2;", table.name, table.targetColumn)
+		err := tx.QueryRowContext(ctx, query, comment.ParentID, targetID).Scan(&grandparentID)
+		if errors.Is(err, sql.ErrNoRows) || grandparentID.Valid {
+			return store.CommentBag{}, store.ErrInvalidParent
+		}
+		if err != nil {
+			return store.CommentBag{}, err
+		}
+
+		parentID = sql.NullInt64{Int64: int64(comment.ParentID), Valid: true}
+	}
+
+	bag := store.CommentBag{
+		ParentID:  comment.ParentID,
+		Body:      comment.Body,
+		CreatedOn: comment.CreatedOn,
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (%s, user_id, parent_id, body, created_on) VALUES ($1, $2, $3, $4, $5) RETURNING id;", table.name, table.targetColumn)
+	err = tx.QueryRowContext(ctx, query, targetID, comment.UserID, parentID, comment.Body, comment.CreatedOn).Scan(&bag.ID)
+	if err != nil {
+		return store.CommentBag{}, err
+	}
+
+	query = "SELECT id, name, image_src FROM users WHERE id = $1;"
+	err = tx.QueryRowContext(ctx, query, comment.UserID).Scan(&bag.Author.ID, &bag.Author.Name, &bag.Author.ImageSource)
+	if err != nil {
+		return store.CommentBag{}, err
+	}
+
+	return bag, tx.Commit()
+}
+
+func (s *Store) commentOwners(ctx context.Context, table commentTable, id int) (string, string, error) {
+	query := fmt.Sprintf("SELECT c.user_id, p.user_id FROM %s c JOIN %s p ON p.id = c.%s WHERE c.id = $1;", table.name, table.posts, table.targetColumn)
+
+	var commentUserID, postUserID string
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&commentUserID, &postUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", store.ErrNotFound
+	}
+
+	return commentUserID, postUserID, err
+}
+
+func (s *Store) deleteComment(ctx context.Context, table commentTable, id int) error {
+	// Replies are removed along with their parent by the foreign key
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", table.name)
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// comments returns a page of the top-level comments on the post, each with
+// its replies. Pages are selected by keyset on (created_on, id).
+func (s *Store) comments(ctx context.Context, table commentTable, targetID any, page store.Page) ([]store.CommentBag, error) {
+	var afterCreatedOn sql.NullTime
+	var afterID sql.NullInt64
+	if page.After != nil {
+		id, err := strconv.Atoi(page.After.ID)
+		if err != nil {
+			return nil, store.ErrInvalidCursor
+		}
+
+		afterCreatedOn = sql.NullTime{Time: page.After.CreatedOn, Valid: true}
+		afterID = sql.NullInt64{Int64: int64(id), Valid: true}
+	}
+
+	query := fmt.Sprintf(`SELECT c.id, c.body, c.created_on, u.id, u.name, u.image_src
+	FROM %s c JOIN users u ON u.id = c.user_id
+	WHERE c.%s = $1 AND c.parent_id IS NULL AND ($2::timestamptz IS NULL OR c.created_on > $2 OR (c.created_on = $2 AND c.id > $3))
+	ORDER BY c.created_on, c.id LIMIT $4;`, table.name, table.targetColumn)
+
+	rows, err := s.db.QueryContext(ctx, query, targetID, afterCreatedOn, afterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []store.CommentBag{}
+	for rows.Next() {
+		var comment store.CommentBag
+		author := &comment.Author
+		if err := rows.Scan(&comment.ID, &comment.Body, &comment.CreatedOn, &author.ID, &author.Name, &author.ImageSource); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	parentIDs := []int{}
+	for _, comment := range comments {
+		parentIDs = append(parentIDs, comment.ID)
+	}
+
+	query = fmt.Sprintf(`SELECT c.id, c.parent_id, c.body, c.created_on, u.id, u.name, u.image_src
+	FROM %s c JOIN users u ON u.id = c.user_id
+	WHERE c.parent_id = ANY($1)
+	ORDER BY c.created_on, c.id;`, table.name)
+
+	replyRows, err := s.db.QueryContext(ctx, query, pq.Array(parentIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer replyRows.Close()
+
+	replies := map[int][]store.CommentBag{}
+	for replyRows.Next() {
+		var reply store.CommentBag
+		author := &reply.Author
+		if err := replyRows.Scan(&reply.ID, &reply.ParentID, &reply.Body, &reply.CreatedOn, &author.ID, &author.Name, &author.ImageSource); err != nil {
+			return nil, err
+		}
+		replies[reply.ParentID] = append(replies[reply.ParentID], reply)
+	}
+	if err := replyRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range comments {
+		comments[i].Replies = replies[comments[i].ID]
+	}
+
+	return comments, nil
+}
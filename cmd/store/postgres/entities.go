@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"on-the-record-api/cmd/store"
+)
+
+// visibleAuthor is a condition on the author u that leaves out private
+// accounts unless the viewer, whose ID is the numbered param, is them or
+// follows them.
+func visibleAuthor(viewerParam int) string {
+	return fmt.Sprintf(
+		"(NOT u.is_private OR u.id = $%[1]d OR u.id IN (SELECT followee_id FROM follower_relation WHERE follower_id = $%[1]d))",
+		viewerParam,
+	)
+}
+
+func (s *Store) Entity(ctx context.Context, entityID string, entityType int, viewerID string) (store.Entity, error) {
+	entity := store.Entity{
+		EntityID:          entityID,
+		Type:              entityType,
+		ScoreDistribution: map[int]int{},
+	}
+
+	query := `SELECT r.score, COUNT(*) FROM reviews r JOIN users u ON u.id = r.user_id
+	WHERE r.entity_id = $1 AND r.type = $2 AND r.visibility = 'public' AND ` + visibleAuthor(3) + `
+	GROUP BY r.score;`
+	rows, err := s.db.QueryContext(ctx, query, entityID, entityType, viewerID)
+	if err != nil {
+		return store.Entity{}, err
+	}
+	defer rows.Close()
+
+	total := 0
+	for rows.Next() {
+		var score, count int
+		if err := rows.Scan(&score, &count); err != nil {
+			return store.Entity{}, err
+		}
+
+		entity.ScoreDistribution[score] = count
+		entity.NumReviews += count
+		total += score * count
+	}
+	if err := rows.Err(); err != nil {
+		return store.Entity{}, err
+	}
+
+	if entity.NumReviews > 0 {
+		entity.AverageScore = float64(total) / float64(entity.NumReviews)
+
+		query = `SELECT r.title, r.subtitle, r.image_src FROM reviews r JOIN users u ON u.id = r.user_id
+		WHERE r.entity_id = $1 AND r.type = $2 AND r.visibility = 'public' AND ` + visibleAuthor(3) + `
+		ORDER BY r.created_on DESC LIMIT 1;`
+		err = s.db.QueryRowContext(ctx, query, entityID, entityType, viewerID).Scan(&entity.Title, &entity.Subtitle, &entity.ImageSource)
+		return entity, err
+	}
+
+	// Nobody has reviewed it, so fall back to how it appears in lists
+	query = `SELECT e.title, e.image_src FROM list_elements e JOIN lists l ON l.id = e.list_id JOIN users u ON u.id = l.user_id
+	WHERE e.entity_id = $1 AND l.type = $2 AND l.visibility = 'public' AND ` + visibleAuthor(3) + `
+	ORDER BY l.created_on DESC LIMIT 1;`
+	err = s.db.QueryRowContext(ctx, query, entityID, entityType, viewerID).Scan(&entity.Title, &entity.ImageSource)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Entity{}, store.ErrNotFound
+	}
+
+	return entity, err
+}
+
+func (s *Store) EntityReviews(ctx context.Context, entityID string, entityType int, viewerID string, limit int) ([]store.Post, error) {
+	query := `SELECT ` + reviewPostColumns + ` FROM reviews r JOIN users u ON u.id = r.user_id
+	WHERE r.entity_id = $1 AND r.type = $2 AND r.visibility = 'public' AND ` + visibleAuthor(3) + `
+	ORDER BY r.user_id IN (SELECT followee_id FROM follower_relation WHERE follower_id = $3) DESC, r.created_on DESC, r.id DESC
+	LIMIT $4;`
+
+	rows, err := s.db.QueryContext(ctx, query, entityID, entityType, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []store.Post{}
+	for rows.Next() {
+		post, err := scanReviewPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (s *Store) EntityLists(ctx context.Context, entityID string, entityType int, viewerID string, limit int) ([]store.Post, error) {
+	query := `SELECT ` + listPostColumns + ` FROM lists l JOIN users u ON u.id = l.user_id
+	WHERE l.type = $2 AND l.visibility = 'public' AND ` + visibleAuthor(3) + `
+		AND l.id IN (SELECT list_id FROM list_elements WHERE entity_id = $1)
+	ORDER BY l.created_on DESC, l.id DESC
+	LIMIT $4;`
+
+	rows, err := s.db.QueryContext(ctx, query, entityID, entityType, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []store.Post{}
+	for rows.Next() {
+		post, err := scanListPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
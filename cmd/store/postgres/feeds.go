@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"on-the-record-api/cmd/store"
+	"strconv"
+)
+
+func (s *Store) Timeline(ctx context.Context, userID string, page store.Page) ([]store.Post, error) {
+	// Followees are selected in the query itself so that user IDs are only
+	// ever passed as parameters and never interpolated into the SQL.
+	whereClause := `(user_id = $1 OR user_id IN (SELECT followee_id FROM follower_relation WHERE follower_id = $1))
+		AND user_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = $1)
+		AND user_id NOT IN (SELECT muted_id FROM user_mutes WHERE muter_id = $1)
+		AND visibility <> 'private'`
+
+	return s.posts(ctx, whereClause, userID, page)
+}
+
+func (s *Store) TimelineAudience(ctx context.Context, authorID string) ([]string, error) {
+	query := `SELECT follower_id FROM follower_relation
+	WHERE followee_id = $1 AND follower_id NOT IN (SELECT muter_id FROM user_mutes WHERE muted_id = $1);`
+
+	rows, err := s.db.QueryContext(ctx, query, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+func (s *Store) Activity(ctx context.Context, userID string, asFollower bool, page store.Page) ([]store.Post, error) {
+	whereClause := "user_id = $1 AND visibility = 'public'"
+	if asFollower {
+		whereClause = "user_id = $1 AND visibility IN ('public', 'followers')"
+	}
+
+	return s.posts(ctx, whereClause, userID, page)
+}
+
+func (s *Store) Drafts(ctx context.Context, userID string, page store.Page) ([]store.Post, error) {
+	return s.posts(ctx, "user_id = $1 AND visibility = 'private'", userID, page)
+}
+
+// posts returns one page of the reviews and lists matching whereClause,
+// newest first. whereClause must be a constant that refers to userID as
+// $1. The reviews and lists are merged and paginated by Postgres so that
+// only the requested page is read. Pages are selected by keyset on
+// (created_on, kind, id) so that posts published while a user scrolls
+// don't shift later pages.
+func (s *Store) posts(ctx context.Context, whereClause string, userID string, page store.Page) ([]store.Post, error) {
+	query := fmt.Sprintf(`SELECT kind, id, entity_id, type, colour, image_src, title, subtitle, score, body, edited_on, ranked, forked_from, updated_on, visibility, created_on, author_id, author_name, author_image_src FROM (
+		SELECT %d AS kind, r.id::text AS id, r.entity_id, r.type, r.colour, r.image_src, r.title, r.subtitle, r.score, r.body, r.edited_on, FALSE AS ranked, NULL::text AS forked_from, NULL::timestamptz AS updated_on, r.visibility, r.created_on, u.id AS author_id, u.name AS author_name, u.image_src AS author_image_src
+		FROM reviews r JOIN users u ON u.id = r.user_id WHERE %s
+		UNION ALL
+		SELECT %d, l.id, '', l.type, l.colour, '', l.title, '', 0, '', NULL::timestamptz, l.ranked, l.forked_from, l.updated_on, l.visibility, l.created_on, u.id, u.name, u.image_src
+		FROM lists l JOIN users u ON u.id = l.user_id WHERE %s
+	) posts
+	WHERE $2::timestamptz IS NULL OR created_on < $2 OR (created_on = $2 AND (kind > $3 OR (kind = $3 AND id < $4)))
+	ORDER BY created_on DESC, kind, id DESC LIMIT $5;`, store.ReviewType, whereClause, store.ListType, whereClause)
+
+	var afterCreatedOn sql.NullTime
+	var afterType sql.NullInt64
+	var afterID sql.NullString
+	if page.After != nil {
+		afterCreatedOn = sql.NullTime{Time: page.After.CreatedOn, Valid: true}
+		afterType = sql.NullInt64{Int64: int64(page.After.Type), Valid: true}
+		afterID = sql.NullString{String: page.After.ID, Valid: true}
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, userID, afterCreatedOn, afterType, afterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []store.Post{}
+	for rows.Next() {
+		var post store.Post
+		var id, entityID, colour, imageSource, title, subtitle, body, visibility string
+		var itemType, score int
+		var editedOn, updatedOn sql.NullTime
+		var ranked bool
+		var forkedFrom sql.NullString
+		author := &post.Author
+		if err := rows.Scan(&post.Type, &id, &entityID, &itemType, &colour, &imageSource, &title, &subtitle, &score, &body, &editedOn, &ranked, &forkedFrom, &updatedOn, &visibility, &post.Timestamp, &author.ID, &author.Name, &author.ImageSource); err != nil {
+			return nil, err
+		}
+
+		if post.Type == store.ReviewType {
+			reviewID, err := strconv.Atoi(id)
+			if err != nil {
+				return nil, err
+			}
+
+			post.Review = store.ReviewBag{
+				ID:          reviewID,
+				EntityID:    entityID,
+				Type:        itemType,
+				Title:       title,
+				Subtitle:    subtitle,
+				Colour:      colour,
+				ImageSource: imageSource,
+				Score:       score,
+				Body:        body,
+				IsEdited:    editedOn.Valid,
+				Visibility:  visibility,
+			}
+			if editedOn.Valid {
+				post.Review.EditedOn = &editedOn.Time
+			}
+		} else {
+			post.List = store.ListBag{
+				ID:         id,
+				Type:       itemType,
+				Title:      title,
+				Colour:     colour,
+				Ranked:     ranked,
+				ForkedFrom: forkedFrom.String,
+				Visibility: visibility,
+			}
+			if updatedOn.Valid {
+				post.List.UpdatedOn = &updatedOn.Time
+			}
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"on-the-record-api/cmd/store"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingDriver is a database/sql driver that records every statement
+// it is asked to run. Every query returns no rows. It is used through
+// sql.OpenDB as its own connector, so it never needs registering.
+type recordingDriver struct {
+	mu         sync.Mutex
+	statements []recordedStatement
+}
+
+type recordedStatement struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+func (d *recordingDriver) Connect(context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *recordingDriver) Driver() driver.Driver { return d }
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{conn: c, query: query}, nil
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *recordingConn) Commit() error { return nil }
+
+func (c *recordingConn) Rollback() error { return nil }
+
+type recordingStmt struct {
+	conn  *recordingConn
+	query string
+}
+
+func (s *recordingStmt) Close() error { return nil }
+
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.record(args)
+	return driver.RowsAffected(0), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.record(args)
+	return recordingRows{}, nil
+}
+
+func (s *recordingStmt) record(args []driver.Value) {
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, recordedStatement{query: s.query, args: args})
+}
+
+type recordingRows struct{}
+
+func (recordingRows) Columns() []string { return nil }
+
+func (recordingRows) Close() error { return nil }
+
+func (recordingRows) Next([]driver.Value) error { return io.EOF }
+
+func TestTimelineDoesNotInterpolateUserIDs(t *testing.T) {
+	const hostileID = "0abc' OR '1'='1"
+
+	recorder := &recordingDriver{}
+	db := sql.OpenDB(recorder)
+	defer db.Close()
+
+	_, err := New(db).Timeline(context.Background(), hostileID, store.Page{Limit: 10})
+	if err != nil {
+		t.Fatalf("Timeline returned an error: %v", err)
+	}
+
+	// Followees are selected within the timeline query, so it is the only
+	// statement
+	if len(recorder.statements) != 1 {
+		t.Fatalf("expected Timeline to run 1 statement, got %d", len(recorder.statements))
+	}
+
+	statement := recorder.statements[0]
+	if strings.Contains(statement.query, hostileID) {
+		t.Errorf("query contains user ID %q:\n%s", hostileID, statement.query)
+	}
+	if len(statement.args) == 0 || statement.args[0] != hostileID {
+		t.Errorf("expected the user ID to be passed as the first query parameter, got %v", statement.args)
+	}
+}
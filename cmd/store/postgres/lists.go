@@ -0,0 +1,384 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"on-the-record-api/cmd/store"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateList(ctx context.Context, list store.List) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO lists (id, user_id, type, title, colour, ranked, forked_from, visibility, created_on) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);"
+
+	id := uuid.NewString()
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		id,
+		list.UserID,
+		list.Type,
+		list.Title,
+		list.Colour,
+		list.Ranked,
+		sql.NullString{String: list.ForkedFrom, Valid: list.ForkedFrom != ""},
+		list.Visibility,
+		list.CreatedOn,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if err := insertListElements(ctx, tx, id, list.UserID, list.ListElements); err != nil {
+		return "", err
+	}
+
+	return id, tx.Commit()
+}
+
+// listPostColumns are the columns read by scanListPost, for a query that
+// joins lists l with their author u.
+const listPostColumns = "l.id, l.type, l.title, l.colour, l.ranked, l.forked_from, l.updated_on, l.visibility, l.created_on, u.id, u.name, u.image_src"
+
+// insertListElements inserts the elements in a single statement, however
+// many there are, placing them in the order given starting from 1.
+// Elements that don't say who added them are attributed to ownerID.
+func insertListElements(ctx context.Context, tx *sql.Tx, listID string, ownerID string, elements []store.ListElement) error {
+	userIDs := make([]string, len(elements))
+	entityIDs := make([]string, len(elements))
+	titles := make([]string, len(elements))
+	imageSources := make([]string, len(elements))
+	notes := make([]string, len(elements))
+	for i, element := range elements {
+		userIDs[i] = element.AddedBy.ID
+		if userIDs[i] == "" {
+			userIDs[i] = ownerID
+		}
+		entityIDs[i] = element.EntityID
+		titles[i] = element.Name
+		imageSources[i] = element.ImageSrc
+		notes[i] = element.Note
+	}
+
+	query := `INSERT INTO list_elements (list_id, user_id, entity_id, title, image_src, note, placement)
+	SELECT $1, e.user_id, e.entity_id, e.title, e.image_src, e.note, e.placement
+	FROM unnest($2::text[], $3::text[], $4::text[], $5::text[], $6::text[]) WITH ORDINALITY AS e (user_id, entity_id, title, image_src, note, placement);`
+
+	_, err := tx.ExecContext(ctx, query, listID, pq.Array(userIDs), pq.Array(entityIDs), pq.Array(titles), pq.Array(imageSources), pq.Array(notes))
+	return err
+}
+
+func (s *Store) List(ctx context.Context, id string) (store.Post, error) {
+	query := "SELECT " + listPostColumns + " FROM lists l JOIN users u ON u.id = l.user_id WHERE l.id = $1;"
+
+	post, err := scanListPost(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Post{}, store.ErrNotFound
+	}
+
+	return post, err
+}
+
+// scanListPost reads a row of listPostColumns into a post, without the
+// list's elements.
+func scanListPost(row scanner) (store.Post, error) {
+	post := store.Post{Type: store.ListType}
+	list := &post.List
+	author := &post.Author
+	var forkedFrom sql.NullString
+	var updatedOn sql.NullTime
+	err := row.Scan(
+		&list.ID,
+		&list.Type,
+		&list.Title,
+		&list.Colour,
+		&list.Ranked,
+		&forkedFrom,
+		&updatedOn,
+		&list.Visibility,
+		&post.Timestamp,
+		&author.ID,
+		&author.Name,
+		&author.ImageSource,
+	)
+	if err != nil {
+		return store.Post{}, err
+	}
+
+	list.ForkedFrom = forkedFrom.String
+	if updatedOn.Valid {
+		list.UpdatedOn = &updatedOn.Time
+	}
+
+	return post, nil
+}
+
+func (s *Store) ListOwner(ctx context.Context, id string) (string, error) {
+	query := "SELECT user_id FROM lists WHERE id = $1"
+
+	var userID string
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", store.ErrNotFound
+	}
+
+	return userID, err
+}
+
+func (s *Store) DeleteList(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM list_elements WHERE list_id = $1;", id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM lists WHERE id = $1;", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) UpdateList(ctx context.Context, id string, update store.ListUpdate) error {
+	query := "UPDATE lists SET title = $2, colour = $3, updated_on = $4, visibility = COALESCE(NULLIF($5, ''), visibility) WHERE id = $1;"
+	result, err := s.db.ExecContext(ctx, query, id, update.Title, update.Colour, update.UpdatedOn, update.Visibility)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateListElements(ctx context.Context, id string, ops []store.ListOperation, updatedOn time.Time) ([]store.ListElement, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the list so that concurrent edits are applied one after another
+	var userID string
+	err = tx.QueryRowContext(ctx, "SELECT user_id FROM lists WHERE id = $1 FOR UPDATE;", id).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT entity_id, title, image_src, note, user_id FROM list_elements WHERE list_id = $1 ORDER BY placement ASC;"
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	elements := []store.ListElement{}
+	for rows.Next() {
+		var element store.ListElement
+		if err := rows.Scan(&element.EntityID, &element.Name, &element.ImageSrc, &element.Note, &element.AddedBy.ID); err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	elements, err = store.ApplyListOperations(elements, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewriting every element is simpler than shifting placements around
+	// and leaves them numbered 1 to n
+	if _, err := tx.ExecContext(ctx, "DELETE FROM list_elements WHERE list_id = $1;", id); err != nil {
+		return nil, err
+	}
+	if err := insertListElements(ctx, tx, id, userID, elements); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE lists SET updated_on = $2 WHERE id = $1;", id, updatedOn); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Read the elements back to fill in the details of who added them
+	listElements, err := s.ListElements(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	return listElements[id], nil
+}
+
+func (s *Store) ListElements(ctx context.Context, listIDs []string) (map[string][]store.ListElement, error) {
+	listElements := map[string][]store.ListElement{}
+	if len(listIDs) == 0 {
+		return listElements, nil
+	}
+
+	query := `SELECT e.list_id, e.entity_id, e.title, e.image_src, e.note, u.id, u.name, u.image_src
+	FROM list_elements e JOIN users u ON u.id = e.user_id
+	WHERE e.list_id = ANY($1) ORDER BY e.list_id, e.placement ASC;`
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(listIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var listID string
+		var listElement store.ListElement
+		addedBy := &listElement.AddedBy
+		if err := rows.Scan(&listID, &listElement.EntityID, &listElement.Name, &listElement.ImageSrc, &listElement.Note, &addedBy.ID, &addedBy.Name, &addedBy.ImageSource); err != nil {
+			return nil, err
+		}
+
+		listElements[listID] = append(listElements[listID], listElement)
+	}
+
+	return listElements, rows.Err()
+}
+
+func (s *Store) ListForkCounts(ctx context.Context, listIDs []string) (map[string]int, error) {
+	counts := map[string]int{}
+	if len(listIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `SELECT l.forked_from, COUNT(*) FROM lists l JOIN users u ON u.id = l.user_id
+	WHERE l.forked_from = ANY($1) AND l.visibility = 'public' AND NOT u.is_private
+	GROUP BY l.forked_from`
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(listIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var listID string
+		var count int
+		if err := rows.Scan(&listID, &count); err != nil {
+			return nil, err
+		}
+		counts[listID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (s *Store) AddListCollaborator(ctx context.Context, listID string, userID string) error {
+	query := "INSERT INTO list_collaborators (list_id, user_id, added_on) VALUES ($1, $2, NOW()) ON CONFLICT DO NOTHING;"
+	_, err := s.db.ExecContext(ctx, query, listID, userID)
+	return err
+}
+
+func (s *Store) RemoveListCollaborator(ctx context.Context, listID string, userID string) error {
+	query := "DELETE FROM list_collaborators WHERE list_id = $1 AND user_id = $2;"
+	_, err := s.db.ExecContext(ctx, query, listID, userID)
+	return err
+}
+
+func (s *Store) IsListCollaborator(ctx context.Context, listID string, userID string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM list_collaborators WHERE list_id = $1 AND user_id = $2);"
+
+	var isCollaborator bool
+	err := s.db.QueryRowContext(ctx, query, listID, userID).Scan(&isCollaborator)
+	return isCollaborator, err
+}
+
+func (s *Store) ListCollaborators(ctx context.Context, listID string) ([]store.UserCondensed, error) {
+	query := "SELECT u.id, u.name, u.image_src FROM list_collaborators c JOIN users u ON c.user_id = u.id WHERE c.list_id = $1 ORDER BY c.added_on"
+	rows, err := s.db.QueryContext(ctx, query, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsersCondensed(rows)
+}
+
+func (s *Store) HasLikedList(ctx context.Context, userID string, listID string) (bool, error) {
+	query := "SELECT COUNT(*) FROM list_likes WHERE user_id = $1 AND list_id = $2"
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, userID, listID).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *Store) LikeList(ctx context.Context, userID string, listID string) error {
+	query := "INSERT INTO list_likes (list_id, user_id) VALUES ($1, $2)"
+	_, err := s.db.ExecContext(ctx, query, listID, userID)
+	return err
+}
+
+func (s *Store) UnlikeList(ctx context.Context, userID string, listID string) error {
+	query := "DELETE FROM list_likes WHERE list_id = $1 AND user_id = $2;"
+	_, err := s.db.ExecContext(ctx, query, listID, userID)
+	return err
+}
+
+func (s *Store) ListLikes(ctx context.Context, listID string) ([]store.UserCondensed, error) {
+	query := "SELECT u.id, u.name, u.image_src FROM list_likes l JOIN users u ON l.user_id = u.id WHERE l.list_id = $1"
+	rows, err := s.db.QueryContext(ctx, query, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsersCondensed(rows)
+}
+
+func (s *Store) ListLikeSummaries(ctx context.Context, viewerID string, listIDs []string) (map[string]store.LikeSummary, error) {
+	summaries := map[string]store.LikeSummary{}
+	if len(listIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := "SELECT list_id, COUNT(*), BOOL_OR(user_id = $2) FROM list_likes WHERE list_id = ANY($1) GROUP BY list_id"
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(listIDs), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var listID string
+		var summary store.LikeSummary
+		if err := rows.Scan(&listID, &summary.Count, &summary.IsLiked); err != nil {
+			return nil, err
+		}
+		summaries[listID] = summary
+	}
+
+	return summaries, rows.Err()
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrating so
+// that two instances starting at once don't both apply the same migration.
+const migrationLockID = 7412_0001
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations reads the embedded migrations, which are named
+// NNNN_description.up.sql and NNNN_description.down.sql, and returns them
+// ordered by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("unexpected migration file %q", fileName)
+		}
+
+		name := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionString, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %q is missing a version prefix", fileName)
+		}
+		version, err := strconv.Atoi(versionString)
+		if err != nil {
+			return nil, fmt.Errorf("migration %q has an invalid version: %w", fileName, err)
+		}
+
+		contents, err := migrationFiles.ReadFile("migrations/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if m.name != name {
+			return nil, fmt.Errorf("migrations %q and %q share version %d", m.name, name, version)
+		}
+
+		if direction == "up" {
+			m.up = string(contents)
+		} else {
+			m.down = string(contents)
+		}
+	}
+
+	migrations := []migration{}
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %q must have both an up and a down file", m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp applies every migration newer than the database's current
+// version and returns the names of the migrations it applied.
+func MigrateUp(ctx context.Context, db *sql.DB) ([]string, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []string{}
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		current, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if m.version <= current {
+				continue
+			}
+
+			err := runMigration(ctx, conn, m.up, "INSERT INTO schema_migrations (version) VALUES ($1)", m.version)
+			if err != nil {
+				return fmt.Errorf("applying %s: %w", m.name, err)
+			}
+			applied = append(applied, m.name)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown rolls back the given number of most recently applied
+// migrations and returns the names of the migrations it rolled back.
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) ([]string, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	rolledBack := []string{}
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		current, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			m := migrations[i]
+			if m.version > current {
+				continue
+			}
+
+			err := runMigration(ctx, conn, m.down, "DELETE FROM schema_migrations WHERE version = $1", m.version)
+			if err != nil {
+				return fmt.Errorf("rolling back %s: %w", m.name, err)
+			}
+			rolledBack = append(rolledBack, m.name)
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Baseline records every migration up to and including version as applied
+// without running them, for databases whose schema was created before
+// migrations were tracked. It refuses to touch a database that already has
+// migrations recorded, and returns the names of those it recorded.
+func Baseline(ctx context.Context, db *sql.DB, version int) ([]string, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	known := false
+	for _, m := range migrations {
+		known = known || m.version == version
+	}
+	if !known {
+		return nil, fmt.Errorf("there is no migration with version %d", version)
+	}
+
+	recorded := []string{}
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		current, err := schemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if current != 0 {
+			return fmt.Errorf("schema is already at version %d", current)
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, m := range migrations {
+			if m.version > version {
+				break
+			}
+
+			if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", m.version); err != nil {
+				return err
+			}
+			recorded = append(recorded, m.name)
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// SchemaVersion returns the version of the most recently applied
+// migration, or 0 if none have been applied.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return 0, err
+	}
+
+	return schemaVersion(ctx, conn)
+}
+
+func withMigrationLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	// Advisory locks belong to a session, so everything has to happen on a
+	// single connection rather than going through the pool.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_on TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`
+	_, err := conn.ExecContext(ctx, query)
+	return err
+}
+
+func schemaVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var version int
+	err := conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// runMigration executes a migration's SQL and records the new version in
+// a single transaction, so a failed migration leaves nothing behind.
+func runMigration(ctx context.Context, conn *sql.Conn, script string, versionQuery string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, versionQuery, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,8 @@
+DROP TABLE list_likes;
+DROP TABLE review_likes;
+DROP TABLE list_elements;
+DROP TABLE lists;
+DROP TABLE reviews;
+DROP TABLE follower_relation;
+DROP TABLE music_notes;
+DROP TABLE users;
@@ -0,0 +1,79 @@
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    colour TEXT NOT NULL DEFAULT '',
+    image_src TEXT NOT NULL DEFAULT '',
+    created_on TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE music_notes (
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    entity_id TEXT NOT NULL,
+    prompt TEXT NOT NULL,
+    image_src TEXT NOT NULL,
+    title TEXT NOT NULL,
+    subtitle TEXT NOT NULL
+);
+
+CREATE INDEX music_notes_user_id_idx ON music_notes (user_id);
+
+CREATE TABLE follower_relation (
+    follower_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE INDEX follower_relation_followee_id_idx ON follower_relation (followee_id);
+
+CREATE TABLE reviews (
+    id SERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    entity_id TEXT NOT NULL,
+    type INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    subtitle TEXT NOT NULL,
+    colour TEXT NOT NULL,
+    image_src TEXT NOT NULL,
+    score INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    created_on TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX reviews_user_id_created_on_idx ON reviews (user_id, created_on DESC);
+
+CREATE TABLE lists (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    colour TEXT NOT NULL,
+    created_on TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX lists_user_id_created_on_idx ON lists (user_id, created_on DESC);
+
+CREATE TABLE list_elements (
+    list_id TEXT NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    entity_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    image_src TEXT NOT NULL,
+    placement INTEGER NOT NULL,
+    PRIMARY KEY (list_id, placement)
+);
+
+CREATE TABLE review_likes (
+    review_id INTEGER NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (review_id, user_id)
+);
+
+CREATE INDEX review_likes_user_id_idx ON review_likes (user_id);
+
+CREATE TABLE list_likes (
+    list_id TEXT NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX list_likes_user_id_idx ON list_likes (user_id);
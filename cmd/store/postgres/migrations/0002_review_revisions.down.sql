@@ -0,0 +1,2 @@
+DROP TABLE review_revisions;
+ALTER TABLE reviews DROP COLUMN edited_on;
@@ -0,0 +1,13 @@
+ALTER TABLE reviews ADD COLUMN edited_on TIMESTAMPTZ;
+
+CREATE TABLE review_revisions (
+    id SERIAL PRIMARY KEY,
+    review_id INTEGER NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    subtitle TEXT NOT NULL,
+    score INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    replaced_on TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX review_revisions_review_id_idx ON review_revisions (review_id, replaced_on DESC);
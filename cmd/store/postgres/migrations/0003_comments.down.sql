@@ -0,0 +1,2 @@
+DROP TABLE list_comments;
+DROP TABLE review_comments;
@@ -0,0 +1,23 @@
+CREATE TABLE review_comments (
+    id SERIAL PRIMARY KEY,
+    review_id INTEGER NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES review_comments (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_on TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX review_comments_review_id_created_on_idx ON review_comments (review_id, created_on, id);
+CREATE INDEX review_comments_parent_id_idx ON review_comments (parent_id);
+
+CREATE TABLE list_comments (
+    id SERIAL PRIMARY KEY,
+    list_id TEXT NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES list_comments (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_on TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX list_comments_list_id_created_on_idx ON list_comments (list_id, created_on, id);
+CREATE INDEX list_comments_parent_id_idx ON list_comments (parent_id);
@@ -0,0 +1,2 @@
+DROP INDEX list_elements_entity_id_idx;
+DROP INDEX reviews_entity_id_type_idx;
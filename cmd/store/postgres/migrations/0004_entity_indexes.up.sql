@@ -0,0 +1,2 @@
+CREATE INDEX reviews_entity_id_type_idx ON reviews (entity_id, type);
+CREATE INDEX list_elements_entity_id_idx ON list_elements (entity_id);
@@ -0,0 +1,3 @@
+-- Lists used to always be a top five, so existing lists are ranked
+ALTER TABLE lists ADD COLUMN ranked BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE lists ALTER COLUMN ranked SET DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE lists DROP COLUMN updated_on;
@@ -0,0 +1 @@
+ALTER TABLE lists ADD COLUMN updated_on TIMESTAMPTZ;
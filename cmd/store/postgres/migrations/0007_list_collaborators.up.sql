@@ -0,0 +1,8 @@
+CREATE TABLE list_collaborators (
+    list_id TEXT NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    added_on TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX list_collaborators_user_id_idx ON list_collaborators (user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE lists ADD COLUMN forked_from TEXT REFERENCES lists (id) ON DELETE SET NULL;
+
+CREATE INDEX lists_forked_from_idx ON lists (forked_from);
@@ -0,0 +1 @@
+ALTER TABLE list_elements DROP COLUMN note;
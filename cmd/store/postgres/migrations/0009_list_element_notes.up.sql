@@ -0,0 +1 @@
+ALTER TABLE list_elements ADD COLUMN note TEXT NOT NULL DEFAULT '';
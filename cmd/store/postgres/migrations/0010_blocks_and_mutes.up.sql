@@ -0,0 +1,15 @@
+CREATE TABLE user_blocks (
+    blocker_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_on TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE INDEX user_blocks_blocked_id_idx ON user_blocks (blocked_id);
+
+CREATE TABLE user_mutes (
+    muter_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    muted_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_on TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (muter_id, muted_id)
+);
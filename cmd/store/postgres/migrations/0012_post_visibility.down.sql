@@ -0,0 +1,2 @@
+ALTER TABLE lists DROP COLUMN visibility;
+ALTER TABLE reviews DROP COLUMN visibility;
@@ -0,0 +1,24 @@
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    review_id INTEGER REFERENCES reviews (id) ON DELETE CASCADE,
+    list_id TEXT REFERENCES lists (id) ON DELETE CASCADE,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_on TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX notifications_user_id_updated_on_idx ON notifications (user_id, updated_on DESC, id DESC);
+
+-- Likes of the same post are collapsed into one notification
+CREATE UNIQUE INDEX notifications_collapsed_idx ON notifications (user_id, kind, COALESCE(review_id, 0), COALESCE(list_id, ''))
+    WHERE kind IN ('review_like', 'list_like');
+
+CREATE TABLE notification_actors (
+    notification_id INTEGER NOT NULL REFERENCES notifications (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_on TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (notification_id, user_id)
+);
+
+CREATE INDEX notification_actors_user_id_idx ON notification_actors (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE list_elements DROP CONSTRAINT IF EXISTS list_elements_user_id_fkey;
+ALTER TABLE list_elements ADD CONSTRAINT list_elements_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+-- The foreign key was created unnamed in 0001, so look up whatever name
+-- Postgres gave it rather than assuming the default.
+DO $$
+DECLARE
+    constraint_name TEXT;
+BEGIN
+    SELECT conname INTO constraint_name FROM pg_constraint
+    WHERE conrelid = 'list_elements'::regclass AND confrelid = 'users'::regclass AND contype = 'f';
+
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE list_elements DROP CONSTRAINT %I', constraint_name);
+    END IF;
+END $$;
+
+ALTER TABLE list_elements ADD CONSTRAINT list_elements_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id);
@@ -0,0 +1 @@
+DROP INDEX user_mutes_muted_id_idx;
@@ -0,0 +1 @@
+CREATE INDEX user_mutes_muted_id_idx ON user_mutes (muted_id);
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"on-the-record-api/cmd/store"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+func (s *Store) Notify(ctx context.Context, notification store.Notification) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reviewID := sql.NullInt64{Int64: int64(notification.ReviewID), Valid: notification.ReviewID != 0}
+	listID := sql.NullString{String: notification.ListID, Valid: notification.ListID != ""}
+
+	query := "INSERT INTO notifications (user_id, kind, review_id, list_id, updated_on) VALUES ($1, $2, $3, $4, $5) RETURNING id;"
+	if notification.Collapses() {
+		// The conflict target matches notifications_collapsed_idx, so a
+		// like of an already liked post updates its notification instead
+		query = `INSERT INTO notifications (user_id, kind, review_id, list_id, updated_on) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, kind, COALESCE(review_id, 0), COALESCE(list_id, '')) WHERE kind IN ('review_like', 'list_like')
+		DO UPDATE SET is_read = FALSE, updated_on = EXCLUDED.updated_on
+		RETURNING id;`
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, query, notification.UserID, notification.Kind, reviewID, listID, notification.CreatedOn).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO notification_actors (notification_id, user_id, created_on) VALUES ($1, $2, $3)
+	ON CONFLICT (notification_id, user_id) DO UPDATE SET created_on = EXCLUDED.created_on;`
+	if _, err := tx.ExecContext(ctx, query, id, notification.ActorID, notification.CreatedOn); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) Notifications(ctx context.Context, userID string, page store.Page) ([]store.NotificationBag, error) {
+	var afterUpdatedOn sql.NullTime
+	var afterID sql.NullInt64
+	if page.After != nil {
+		id, err := strconv.Atoi(page.After.ID)
+		if err != nil {
+			return nil, store.ErrInvalidCursor
+		}
+
+		afterUpdatedOn = sql.NullTime{Time: page.After.CreatedOn, Valid: true}
+		afterID = sql.NullInt64{Int64: int64(id), Valid: true}
+	}
+
+	// Joining on the actor counts leaves out notifications whose actors
+	// have all deleted their accounts
+	query := `SELECT n.id, n.kind, n.review_id, n.list_id, n.is_read, n.updated_on, a.count
+	FROM notifications n JOIN (SELECT notification_id, COUNT(*) AS count FROM notification_actors GROUP BY notification_id) a ON a.notification_id = n.id
+	WHERE n.user_id = $1 AND ($2::timestamptz IS NULL OR n.updated_on < $2 OR (n.updated_on = $2 AND n.id < $3))
+	ORDER BY n.updated_on DESC, n.id DESC LIMIT $4;`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, afterUpdatedOn, afterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []store.NotificationBag{}
+	for rows.Next() {
+		notification := store.NotificationBag{Actors: []store.UserCondensed{}}
+		var reviewID sql.NullInt64
+		var listID sql.NullString
+		if err := rows.Scan(&notification.ID, &notification.Kind, &reviewID, &listID, &notification.IsRead, &notification.UpdatedOn, &notification.ActorCount); err != nil {
+			return nil, err
+		}
+		notification.ReviewID = int(reviewID.Int64)
+		notification.ListID = listID.String
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(notifications) == 0 {
+		return notifications, nil
+	}
+
+	ids := []int{}
+	for _, notification := range notifications {
+		ids = append(ids, notification.ID)
+	}
+
+	query = `SELECT a.notification_id, u.id, u.name, u.image_src FROM (
+		SELECT notification_id, user_id, created_on, ROW_NUMBER() OVER (PARTITION BY notification_id ORDER BY created_on DESC) AS rank
+		FROM notification_actors WHERE notification_id = ANY($1)
+	) a JOIN users u ON u.id = a.user_id
+	WHERE a.rank <= $2
+	ORDER BY a.notification_id, a.created_on DESC;`
+
+	actorRows, err := s.db.QueryContext(ctx, query, pq.Array(ids), store.MaxNotificationActors)
+	if err != nil {
+		return nil, err
+	}
+	defer actorRows.Close()
+
+	actors := map[int][]store.UserCondensed{}
+	for actorRows.Next() {
+		var notificationID int
+		var actor store.UserCondensed
+		if err := actorRows.Scan(&notificationID, &actor.ID, &actor.Name, &actor.ImageSource); err != nil {
+			return nil, err
+		}
+		actors[notificationID] = append(actors[notificationID], actor)
+	}
+	if err := actorRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range notifications {
+		notifications[i].Actors = append(notifications[i].Actors, actors[notifications[i].ID]...)
+	}
+
+	return notifications, nil
+}
+
+func (s *Store) UnreadNotificationCount(ctx context.Context, userID string) (int, error) {
+	query := `SELECT COUNT(*) FROM notifications n
+	WHERE n.user_id = $1 AND NOT n.is_read AND EXISTS (SELECT 1 FROM notification_actors a WHERE a.notification_id = n.id);`
+
+	var count int
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+func (s *Store) MarkNotificationsRead(ctx context.Context, userID string, ids []int) error {
+	query := "UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND NOT is_read AND (COALESCE(cardinality($2::int[]), 0) = 0 OR id = ANY($2));"
+	_, err := s.db.ExecContext(ctx, query, userID, pq.Array(ids))
+	return err
+}
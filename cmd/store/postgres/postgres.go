@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"on-the-record-api/cmd/store"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+var _ store.Store = (*Store)(nil)
+
+// Store implements store.Store on top of Postgres.
+type Store struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Config holds the connection details and pool settings for the database.
+type Config struct {
+	User     string
+	Password string
+	Host     string
+	Name     string
+	SSLMode  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ConnectTimeout bounds how long Open waits for the database to respond.
+	ConnectTimeout time.Duration
+}
+
+// ConfigFromEnv reads the database config from the environment. The pool
+// settings fall back to defaults when unset or invalid.
+func ConfigFromEnv() Config {
+	return Config{
+		User:            os.Getenv("DB_USER"),
+		Password:        os.Getenv("DB_PASSWORD"),
+		Host:            os.Getenv("DB_HOST"),
+		Name:            stringFromEnv("DB_NAME", "neondb"),
+		SSLMode:         stringFromEnv("DB_SSLMODE", "verify-full"),
+		MaxOpenConns:    intFromEnv("DB_MAX_OPEN_CONNS", 10),
+		MaxIdleConns:    intFromEnv("DB_MAX_IDLE_CONNS", 5),
+		ConnMaxLifetime: durationFromEnv("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: durationFromEnv("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		ConnectTimeout:  durationFromEnv("DB_CONNECT_TIMEOUT", 10*time.Second),
+	}
+}
+
+// Open creates the connection pool described by cfg and checks that the
+// database is reachable. The returned *sql.DB is meant to be shared for
+// the lifetime of the process.
+func Open(cfg Config) (*sql.DB, error) {
+	connStr := fmt.Sprintf("user=%s password=%s dbname=%s host=%s sslmode=%s", cfg.User, cfg.Password, cfg.Name, cfg.Host, cfg.SSLMode)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not reach Postgres: %w", err)
+	}
+
+	return db, nil
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func stringFromEnv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		slog.Error("invalid value for environment variable, using default", "key", key, "value", value)
+		return fallback
+	}
+
+	return parsed
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		slog.Error("invalid value for environment variable, using default", "key", key, "value", value)
+		return fallback
+	}
+
+	return parsed
+}
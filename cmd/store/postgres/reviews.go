@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"on-the-record-api/cmd/store"
+
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateReview(ctx context.Context, review store.Review) error {
+	query := "INSERT INTO reviews (user_id, entity_id, type, title, subtitle, colour, image_src, score, body, visibility, created_on) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);"
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		review.UserID,
+		review.EntityID,
+		review.Type,
+		review.Title,
+		review.Subtitle,
+		review.Colour,
+		review.ImageSource,
+		review.Score,
+		review.Body,
+		review.Visibility,
+		review.CreatedOn,
+	)
+	return err
+}
+
+// reviewPostColumns are the columns read by scanReviewPost, for a query
+// that joins reviews r with their author u.
+const reviewPostColumns = "r.id, r.entity_id, r.type, r.colour, r.image_src, r.title, r.subtitle, r.score, r.body, r.edited_on, r.visibility, r.created_on, u.id, u.name, u.image_src"
+
+func (s *Store) Review(ctx context.Context, id int) (store.Post, error) {
+	query := "SELECT " + reviewPostColumns + " FROM reviews r JOIN users u ON u.id = r.user_id WHERE r.id = $1;"
+
+	post, err := scanReviewPost(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.Post{}, store.ErrNotFound
+	}
+
+	return post, err
+}
+
+// scanReviewPost reads a row of reviewPostColumns into a post.
+func scanReviewPost(row scanner) (store.Post, error) {
+	post := store.Post{Type: store.ReviewType}
+	review := &post.Review
+	author := &post.Author
+	var editedOn sql.NullTime
+	err := row.Scan(
+		&review.ID,
+		&review.EntityID,
+		&review.Type,
+		&review.Colour,
+		&review.ImageSource,
+		&review.Title,
+		&review.Subtitle,
+		&review.Score,
+		&review.Body,
+		&editedOn,
+		&review.Visibility,
+		&post.Timestamp,
+		&author.ID,
+		&author.Name,
+		&author.ImageSource,
+	)
+	if err != nil {
+		return store.Post{}, err
+	}
+
+	if editedOn.Valid {
+		review.IsEdited = true
+		review.EditedOn = &editedOn.Time
+	}
+
+	return post, nil
+}
+
+func (s *Store) ReviewOwner(ctx context.Context, id int) (string, error) {
+	query := "SELECT user_id FROM reviews WHERE id = $1"
+
+	var userID string
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", store.ErrNotFound
+	}
+
+	return userID, err
+}
+
+func (s *Store) DeleteReview(ctx context.Context, id int) error {
+	query := "DELETE FROM reviews WHERE id = $1;"
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (s *Store) UpdateReview(ctx context.Context, id int, edit store.ReviewEdit) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the review so that concurrent edits each keep the version they
+	// replaced
+	var previous store.ReviewRevision
+	query := "SELECT title, subtitle, score, body FROM reviews WHERE id = $1 FOR UPDATE;"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&previous.Title, &previous.Subtitle, &previous.Score, &previous.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if edit.ChangesContents(previous) {
+		query = "INSERT INTO review_revisions (review_id, title, subtitle, score, body, replaced_on) VALUES ($1, $2, $3, $4, $5, $6);"
+		_, err = tx.ExecContext(ctx, query, id, previous.Title, previous.Subtitle, previous.Score, previous.Body, edit.EditedOn)
+		if err != nil {
+			return err
+		}
+
+		query = "UPDATE reviews SET title = $2, subtitle = $3, score = $4, body = $5, edited_on = $6 WHERE id = $1;"
+		_, err = tx.ExecContext(ctx, query, id, edit.Title, edit.Subtitle, edit.Score, edit.Body, edit.EditedOn)
+		if err != nil {
+			return err
+		}
+	}
+
+	if edit.Visibility != "" {
+		query = "UPDATE reviews SET visibility = $2 WHERE id = $1;"
+		if _, err := tx.ExecContext(ctx, query, id, edit.Visibility); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) ReviewRevisions(ctx context.Context, id int) ([]store.ReviewRevision, error) {
+	query := "SELECT title, subtitle, score, body, replaced_on FROM review_revisions WHERE review_id = $1 ORDER BY replaced_on DESC, id DESC;"
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []store.ReviewRevision{}
+	for rows.Next() {
+		var revision store.ReviewRevision
+		if err := rows.Scan(&revision.Title, &revision.Subtitle, &revision.Score, &revision.Body, &revision.ReplacedOn); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (s *Store) HasLikedReview(ctx context.Context, userID string, reviewID int) (bool, error) {
+	query := "SELECT COUNT(*) FROM review_likes WHERE user_id = $1 AND review_id = $2"
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, userID, reviewID).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *Store) LikeReview(ctx context.Context, userID string, reviewID int) error {
+	query := "INSERT INTO review_likes (review_id, user_id) VALUES ($1, $2)"
+	_, err := s.db.ExecContext(ctx, query, reviewID, userID)
+	return err
+}
+
+func (s *Store) UnlikeReview(ctx context.Context, userID string, reviewID int) error {
+	query := "DELETE FROM review_likes WHERE review_id = $1 AND user_id = $2;"
+	_, err := s.db.ExecContext(ctx, query, reviewID, userID)
+	return err
+}
+
+func (s *Store) ReviewLikes(ctx context.Context, reviewID int) ([]store.UserCondensed, error) {
+	query := "SELECT u.id, u.name, u.image_src FROM review_likes l JOIN users u ON l.user_id = u.id WHERE l.review_id = $1"
+	rows, err := s.db.QueryContext(ctx, query, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsersCondensed(rows)
+}
+
+func (s *Store) ReviewLikeSummaries(ctx context.Context, viewerID string, reviewIDs []int) (map[int]store.LikeSummary, error) {
+	summaries := map[int]store.LikeSummary{}
+	if len(reviewIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := "SELECT review_id, COUNT(*), BOOL_OR(user_id = $2) FROM review_likes WHERE review_id = ANY($1) GROUP BY review_id"
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(reviewIDs), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewID int
+		var summary store.LikeSummary
+		if err := rows.Scan(&reviewID, &summary.Count, &summary.IsLiked); err != nil {
+			return nil, err
+		}
+		summaries[reviewID] = summary
+	}
+
+	return summaries, rows.Err()
+}
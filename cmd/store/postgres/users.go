@@ -0,0 +1,412 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"on-the-record-api/cmd/store"
+
+	"github.com/lib/pq"
+)
+
+func (s *Store) GetUser(ctx context.Context, id string) (store.User, error) {
+	query := `SELECT u.id, u.name, u.colour, u.image_src, u.is_private, u.created_on,
+		(SELECT count(*) FROM follower_relation WHERE followee_id = u.id),
+		(SELECT count(*) FROM follower_relation WHERE follower_id = u.id),
+		(SELECT count(*) FROM reviews WHERE user_id = u.id AND visibility <> 'private'),
+		(SELECT count(*) FROM lists WHERE user_id = u.id AND visibility <> 'private')
+		FROM users u WHERE u.id = $1`
+
+	var user store.User
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Colour,
+		&user.ImageSource,
+		&user.IsPrivate,
+		&user.CreatedOn,
+		&user.Followers,
+		&user.Following,
+		&user.Reviews,
+		&user.Lists,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.User{}, store.ErrNotFound
+	}
+	if err != nil {
+		return store.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *Store) GetUsers(ctx context.Context, ids []string) ([]store.UserCondensed, error) {
+	if len(ids) == 0 {
+		return []store.UserCondensed{}, nil
+	}
+
+	whereClause := "(id = $1)"
+	params := []any{ids[0]}
+	for i, id := range ids {
+		if i == 0 {
+			continue
+		}
+
+		whereClause += fmt.Sprintf(" OR (id = $%d)", i+1)
+		params = append(params, id)
+	}
+
+	query := "SELECT id, name, image_src FROM users WHERE " + whereClause
+	rows, err := s.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsersCondensed(rows)
+}
+
+func (s *Store) SearchUsers(ctx context.Context, prefix string, limit int) ([]store.UserCondensed, error) {
+	query := "SELECT id, name, image_src FROM users WHERE name ILIKE FORMAT('%s%%', $1::text) LIMIT $2"
+	rows, err := s.db.QueryContext(ctx, query, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsersCondensed(rows)
+}
+
+func (s *Store) UserExists(ctx context.Context, id string) (bool, error) {
+	query := "SELECT COUNT(*) FROM users WHERE id = $1"
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *Store) IsPrivate(ctx context.Context, id string) (bool, error) {
+	query := "SELECT is_private FROM users WHERE id = $1"
+
+	var isPrivate bool
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&isPrivate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, store.ErrNotFound
+	}
+
+	return isPrivate, err
+}
+
+func (s *Store) CreateUser(ctx context.Context, user store.User) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO users (id, name, colour, image_src, is_private, created_on) VALUES ($1, $2, $3, $4, $5, $6);"
+	_, err = tx.ExecContext(ctx, query, user.ID, user.Name, user.Colour, user.ImageSource, user.IsPrivate, user.CreatedOn)
+	if err != nil {
+		return err
+	}
+
+	if err := insertMusicNotes(ctx, tx, user.ID, user.MusicNotes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) UpdateUser(ctx context.Context, user store.User) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET name = $2, colour = $3, image_src = $4, is_private = $5 WHERE id = $1;"
+	_, err = tx.ExecContext(ctx, query, user.ID, user.Name, user.Colour, user.ImageSource, user.IsPrivate)
+	if err != nil {
+		return err
+	}
+
+	// Delete existing music notes in order to overwrite them with the new ones
+	query = "DELETE FROM music_notes WHERE user_id = $1"
+	if _, err := tx.ExecContext(ctx, query, user.ID); err != nil {
+		return err
+	}
+
+	if err := insertMusicNotes(ctx, tx, user.ID, user.MusicNotes); err != nil {
+		return err
+	}
+
+	// Anyone can follow a public account, so requests that were waiting on
+	// approval become follows
+	if !user.IsPrivate {
+		queries := []string{
+			`INSERT INTO follower_relation (follower_id, followee_id)
+			SELECT follower_id, followee_id FROM follow_requests WHERE followee_id = $1
+			ON CONFLICT DO NOTHING;`,
+			"DELETE FROM follow_requests WHERE followee_id = $1;",
+		}
+		for _, query := range queries {
+			if _, err := tx.ExecContext(ctx, query, user.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		"DELETE FROM music_notes WHERE user_id = $1",
+		"DELETE FROM lists WHERE user_id = $1",
+		// Elements the user added to other people's lists stay, credited to
+		// the list's owner, so that those lists keep their placements
+		"UPDATE list_elements e SET user_id = l.user_id FROM lists l WHERE l.id = e.list_id AND e.user_id = $1",
+		"DELETE FROM reviews WHERE user_id = $1",
+		"DELETE FROM follower_relation WHERE follower_id = $1 OR followee_id = $1",
+		"DELETE FROM users WHERE id = $1",
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) MusicNotes(ctx context.Context, userID string) ([]store.MusicNote, error) {
+	query := "SELECT entity_id, prompt, image_src, title, subtitle FROM music_notes WHERE user_id = $1;"
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	musicNotes := []store.MusicNote{}
+	for rows.Next() {
+		var musicNote store.MusicNote
+		if err := rows.Scan(&musicNote.EntityID, &musicNote.Prompt, &musicNote.ImageSource, &musicNote.Title, &musicNote.Subtitle); err != nil {
+			return nil, err
+		}
+		musicNotes = append(musicNotes, musicNote)
+	}
+
+	return musicNotes, rows.Err()
+}
+
+func (s *Store) Follow(ctx context.Context, followerID string, followeeID string) error {
+	query := "INSERT INTO follower_relation (follower_id, followee_id) VALUES ($1, $2);"
+	_, err := s.db.ExecContext(ctx, query, followerID, followeeID)
+	return err
+}
+
+func (s *Store) Unfollow(ctx context.Context, followerID string, followeeID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		"DELETE FROM follower_relation WHERE follower_id = $1 AND followee_id = $2;",
+		"DELETE FROM follow_requests WHERE follower_id = $1 AND followee_id = $2;",
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, followerID, followeeID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) IsFollowing(ctx context.Context, followerID string, followeeID string) (bool, error) {
+	query := "SELECT count(*) FROM follower_relation WHERE follower_id = $1 AND followee_id = $2"
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, followerID, followeeID).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *Store) RequestFollow(ctx context.Context, followerID string, followeeID string) error {
+	query := "INSERT INTO follow_requests (follower_id, followee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;"
+	_, err := s.db.ExecContext(ctx, query, followerID, followeeID)
+	return err
+}
+
+func (s *Store) HasRequestedFollow(ctx context.Context, followerID string, followeeID string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM follow_requests WHERE follower_id = $1 AND followee_id = $2);"
+
+	var requested bool
+	err := s.db.QueryRowContext(ctx, query, followerID, followeeID).Scan(&requested)
+	return requested, err
+}
+
+func (s *Store) FollowRequests(ctx context.Context, followeeID string) ([]store.UserCondensed, error) {
+	query := `SELECT u.id, u.name, u.image_src FROM follow_requests f JOIN users u ON u.id = f.follower_id
+	WHERE f.followee_id = $1 ORDER BY f.created_on`
+	rows, err := s.db.QueryContext(ctx, query, followeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsersCondensed(rows)
+}
+
+func (s *Store) ApproveFollowRequest(ctx context.Context, followeeID string, followerID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteFollowRequest(ctx, tx, followeeID, followerID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO follower_relation (follower_id, followee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;"
+	if _, err := tx.ExecContext(ctx, query, followerID, followeeID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DenyFollowRequest(ctx context.Context, followeeID string, followerID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteFollowRequest(ctx, tx, followeeID, followerID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteFollowRequest removes the request, returning ErrNotFound if there
+// wasn't one.
+func deleteFollowRequest(ctx context.Context, tx *sql.Tx, followeeID string, followerID string) error {
+	query := "DELETE FROM follow_requests WHERE follower_id = $1 AND followee_id = $2;"
+	result, err := tx.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) FollowingAmong(ctx context.Context, followerID string, userIDs []string) (map[string]bool, error) {
+	following := map[string]bool{}
+	if len(userIDs) == 0 {
+		return following, nil
+	}
+
+	query := "SELECT followee_id FROM follower_relation WHERE follower_id = $1 AND followee_id = ANY($2)"
+	rows, err := s.db.QueryContext(ctx, query, followerID, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var followeeID string
+		if err := rows.Scan(&followeeID); err != nil {
+			return nil, err
+		}
+		following[followeeID] = true
+	}
+
+	return following, rows.Err()
+}
+
+func (s *Store) Followers(ctx context.Context, userID string, mutualsOnly bool, page store.Page) ([]store.UserCondensed, error) {
+	query := `SELECT u.id, u.name, u.image_src FROM follower_relation f JOIN users u ON u.id = f.follower_id
+	WHERE f.followee_id = $1
+	AND (NOT $2 OR EXISTS (SELECT 1 FROM follower_relation m WHERE m.follower_id = $1 AND m.followee_id = f.follower_id))
+	AND ($3::text IS NULL OR u.id > $3)
+	ORDER BY u.id LIMIT $4`
+
+	return s.followPage(ctx, query, userID, mutualsOnly, page)
+}
+
+func (s *Store) Following(ctx context.Context, userID string, mutualsOnly bool, page store.Page) ([]store.UserCondensed, error) {
+	query := `SELECT u.id, u.name, u.image_src FROM follower_relation f JOIN users u ON u.id = f.followee_id
+	WHERE f.follower_id = $1
+	AND (NOT $2 OR EXISTS (SELECT 1 FROM follower_relation m WHERE m.follower_id = f.followee_id AND m.followee_id = $1))
+	AND ($3::text IS NULL OR u.id > $3)
+	ORDER BY u.id LIMIT $4`
+
+	return s.followPage(ctx, query, userID, mutualsOnly, page)
+}
+
+// followPage runs a Followers or Following query, which takes the user
+// ID, the mutuals flag, the ID to start after and the limit.
+func (s *Store) followPage(ctx context.Context, query string, userID string, mutualsOnly bool, page store.Page) ([]store.UserCondensed, error) {
+	var afterID sql.NullString
+	if page.After != nil {
+		afterID = sql.NullString{String: page.After.ID, Valid: true}
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, userID, mutualsOnly, afterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsersCondensed(rows)
+}
+
+func insertMusicNotes(ctx context.Context, tx *sql.Tx, userID string, musicNotes []store.MusicNote) error {
+	query := "INSERT INTO music_notes (user_id, entity_id, prompt, image_src, title, subtitle) VALUES ($1, $2, $3, $4, $5, $6);"
+	for _, musicNote := range musicNotes {
+		_, err := tx.ExecContext(ctx, query, userID, musicNote.EntityID, musicNote.Prompt, musicNote.ImageSource, musicNote.Title, musicNote.Subtitle)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanUsersCondensed(rows *sql.Rows) ([]store.UserCondensed, error) {
+	users := []store.UserCondensed{}
+	for rows.Next() {
+		var user store.UserCondensed
+		if err := rows.Scan(&user.ID, &user.Name, &user.ImageSource); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
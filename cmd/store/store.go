@@ -0,0 +1,249 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when the requested row does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidParent is returned when a reply's parent comment is not a
+// top-level comment on the same review or list.
+var ErrInvalidParent = errors.New("invalid parent comment")
+
+// Store is the storage layer used by the handlers. There is a Postgres
+// implementation for production and an in-memory implementation for
+// tests and local development.
+type Store interface {
+	// Ping reports whether the underlying storage is reachable.
+	Ping(ctx context.Context) error
+
+	UserStore
+	MusicNoteStore
+	FollowStore
+	BlockStore
+	ReviewStore
+	ListStore
+	LikeStore
+	CommentStore
+	FeedStore
+	EntityStore
+	NotificationStore
+}
+
+type UserStore interface {
+	// GetUser returns the user with the given ID, including their follower,
+	// following, review and list counts.
+	GetUser(ctx context.Context, id string) (User, error)
+	GetUsers(ctx context.Context, ids []string) ([]UserCondensed, error)
+	SearchUsers(ctx context.Context, prefix string, limit int) ([]UserCondensed, error)
+	UserExists(ctx context.Context, id string) (bool, error)
+	// IsPrivate reports whether the user has a private account, or returns
+	// ErrNotFound if there is no such user.
+	IsPrivate(ctx context.Context, id string) (bool, error)
+	// CreateUser inserts the user along with their music notes.
+	CreateUser(ctx context.Context, user User) error
+	// UpdateUser updates the user's profile and replaces their music notes.
+	// If the account is public, any pending follow requests for it become
+	// follows.
+	UpdateUser(ctx context.Context, user User) error
+	// DeleteUser removes the user and everything they have posted. Elements
+	// they added to other users' lists are kept and credited to the list's
+	// owner.
+	DeleteUser(ctx context.Context, id string) error
+}
+
+type MusicNoteStore interface {
+	MusicNotes(ctx context.Context, userID string) ([]MusicNote, error)
+}
+
+type FollowStore interface {
+	Follow(ctx context.Context, followerID string, followeeID string) error
+	// Unfollow removes the follow, or withdraws the follow request if it
+	// hasn't been approved yet.
+	Unfollow(ctx context.Context, followerID string, followeeID string) error
+	IsFollowing(ctx context.Context, followerID string, followeeID string) (bool, error)
+	// RequestFollow asks to follow a private account. Asking again does
+	// nothing.
+	RequestFollow(ctx context.Context, followerID string, followeeID string) error
+	HasRequestedFollow(ctx context.Context, followerID string, followeeID string) (bool, error)
+	// FollowRequests returns the users waiting for followeeID to approve
+	// their follow requests, oldest request first.
+	FollowRequests(ctx context.Context, followeeID string) ([]UserCondensed, error)
+	// ApproveFollowRequest turns followerID's request into a follow. It
+	// returns ErrNotFound if there is no such request.
+	ApproveFollowRequest(ctx context.Context, followeeID string, followerID string) error
+	// DenyFollowRequest removes followerID's request. It returns
+	// ErrNotFound if there is no such request.
+	DenyFollowRequest(ctx context.Context, followeeID string, followerID string) error
+	// FollowingAmong reports which of the given users followerID follows.
+	FollowingAmong(ctx context.Context, followerID string, userIDs []string) (map[string]bool, error)
+	// Followers returns a page of the users that follow userID, ordered by
+	// user ID. With mutualsOnly, only those that userID follows back are
+	// included. Page cursors hold the last user's ID.
+	Followers(ctx context.Context, userID string, mutualsOnly bool, page Page) ([]UserCondensed, error)
+	// Following returns a page of the users that userID follows, in the
+	// same way as Followers.
+	Following(ctx context.Context, userID string, mutualsOnly bool, page Page) ([]UserCondensed, error)
+}
+
+// BlockStore keeps track of the users that each user has blocked or muted.
+type BlockStore interface {
+	// Block stops blockedID from interacting with blockerID and removes
+	// any follows or follow requests between them. It returns ErrNotFound if blockedID
+	// doesn't exist.
+	Block(ctx context.Context, blockerID string, blockedID string) error
+	Unblock(ctx context.Context, blockerID string, blockedID string) error
+	// IsBlocked reports whether either user has blocked the other.
+	IsBlocked(ctx context.Context, userID string, otherID string) (bool, error)
+	// Mute hides mutedID's posts from muterID's timeline. It returns
+	// ErrNotFound if mutedID doesn't exist.
+	Mute(ctx context.Context, muterID string, mutedID string) error
+	Unmute(ctx context.Context, muterID string, mutedID string) error
+}
+
+type ReviewStore interface {
+	CreateReview(ctx context.Context, review Review) error
+	// Review returns the review along with its author, as a post.
+	Review(ctx context.Context, id int) (Post, error)
+	// ReviewOwner returns the ID of the user that wrote the review.
+	ReviewOwner(ctx context.Context, id int) (string, error)
+	DeleteReview(ctx context.Context, id int) error
+	// UpdateReview replaces the contents of the review, keeping its previous
+	// contents as a revision if they changed, and changes its visibility if
+	// one is given.
+	UpdateReview(ctx context.Context, id int, edit ReviewEdit) error
+	// ReviewRevisions returns the earlier versions of the review, newest
+	// first.
+	ReviewRevisions(ctx context.Context, id int) ([]ReviewRevision, error)
+}
+
+type ListStore interface {
+	// CreateList inserts the list and its elements and returns the new
+	// list's ID. A list forked from another has ForkedFrom set.
+	CreateList(ctx context.Context, list List) (string, error)
+	// List returns the list along with its author, as a post. The list's
+	// elements are fetched separately with ListElements.
+	List(ctx context.Context, id string) (Post, error)
+	// ListOwner returns the ID of the user that created the list.
+	ListOwner(ctx context.Context, id string) (string, error)
+	DeleteList(ctx context.Context, id string) error
+	// UpdateList changes the list's title and colour, and its visibility
+	// if one is given.
+	UpdateList(ctx context.Context, id string, update ListUpdate) error
+	// UpdateListElements applies the operations to the list's elements in
+	// a single transaction, keeping placements contiguous, and returns the
+	// resulting elements. It returns an error wrapping ErrInvalidListEdit
+	// if any operation can't be applied.
+	UpdateListElements(ctx context.Context, id string, ops []ListOperation, updatedOn time.Time) ([]ListElement, error)
+	// ListElements returns the elements of each of the given lists in
+	// order, keyed by list ID.
+	ListElements(ctx context.Context, listIDs []string) (map[string][]ListElement, error)
+
+	// ListForkCounts returns how many lists have been forked from each of
+	// the given lists, keyed by list ID. Only public forks by public
+	// accounts are counted, so that the counts don't give away posts that
+	// are hidden from some viewers.
+	ListForkCounts(ctx context.Context, listIDs []string) (map[string]int, error)
+	// AddListCollaborator lets the user add and reorder the list's
+	// elements. Adding an existing collaborator does nothing.
+	AddListCollaborator(ctx context.Context, listID string, userID string) error
+	RemoveListCollaborator(ctx context.Context, listID string, userID string) error
+	IsListCollaborator(ctx context.Context, listID string, userID string) (bool, error)
+	ListCollaborators(ctx context.Context, listID string) ([]UserCondensed, error)
+}
+
+type LikeStore interface {
+	HasLikedReview(ctx context.Context, userID string, reviewID int) (bool, error)
+	LikeReview(ctx context.Context, userID string, reviewID int) error
+	UnlikeReview(ctx context.Context, userID string, reviewID int) error
+	ReviewLikes(ctx context.Context, reviewID int) ([]UserCondensed, error)
+	// ReviewLikeSummaries returns how many likes each of the given reviews
+	// has and whether viewerID is among them, keyed by review ID.
+	ReviewLikeSummaries(ctx context.Context, viewerID string, reviewIDs []int) (map[int]LikeSummary, error)
+
+	HasLikedList(ctx context.Context, userID string, listID string) (bool, error)
+	LikeList(ctx context.Context, userID string, listID string) error
+	UnlikeList(ctx context.Context, userID string, listID string) error
+	ListLikes(ctx context.Context, listID string) ([]UserCondensed, error)
+	// ListLikeSummaries returns how many likes each of the given lists has
+	// and whether viewerID is among them, keyed by list ID.
+	ListLikeSummaries(ctx context.Context, viewerID string, listIDs []string) (map[string]LikeSummary, error)
+}
+
+// CommentStore holds the comments on reviews and lists. Comments can be
+// replied to, but replies can't, so threads are one level deep.
+type CommentStore interface {
+	// CreateReviewComment adds the comment to the review and returns it.
+	// It returns ErrInvalidParent if the comment replies to a comment that
+	// is itself a reply or belongs to another review.
+	CreateReviewComment(ctx context.Context, reviewID int, comment Comment) (CommentBag, error)
+	// ReviewCommentOwners returns the IDs of the comment's author and of
+	// the author of the review it was left on.
+	ReviewCommentOwners(ctx context.Context, id int) (commentUserID string, reviewUserID string, err error)
+	// DeleteReviewComment removes the comment and its replies.
+	DeleteReviewComment(ctx context.Context, id int) error
+	// ReviewComments returns a page of the review's top-level comments,
+	// oldest first, each with all of its replies.
+	ReviewComments(ctx context.Context, reviewID int, page Page) ([]CommentBag, error)
+	// ReviewCommentCounts returns how many comments and replies each of the
+	// given reviews has, keyed by review ID.
+	ReviewCommentCounts(ctx context.Context, reviewIDs []int) (map[int]int, error)
+
+	CreateListComment(ctx context.Context, listID string, comment Comment) (CommentBag, error)
+	ListCommentOwners(ctx context.Context, id int) (commentUserID string, listUserID string, err error)
+	DeleteListComment(ctx context.Context, id int) error
+	ListComments(ctx context.Context, listID string, page Page) ([]CommentBag, error)
+	ListCommentCounts(ctx context.Context, listIDs []string) (map[string]int, error)
+}
+
+// FeedStore returns pages of reviews and lists merged together, newest
+// first.
+type FeedStore interface {
+	// Timeline returns a page of the reviews and lists posted by the user
+	// and the users they follow, leaving out users they have blocked or
+	// muted and any drafts.
+	Timeline(ctx context.Context, userID string, page Page) ([]Post, error)
+	// TimelineAudience returns the IDs of the users whose timelines show
+	// authorID's posts: their followers, except those that have muted them.
+	TimelineAudience(ctx context.Context, authorID string) ([]string, error)
+	// Activity returns a page of the reviews and lists the user has
+	// published. Posts for followers only are included when asFollower
+	// is set.
+	Activity(ctx context.Context, userID string, asFollower bool, page Page) ([]Post, error)
+	// Drafts returns a page of the user's private reviews and lists.
+	Drafts(ctx context.Context, userID string, page Page) ([]Post, error)
+}
+
+// EntityStore gathers everything that has been posted about an entity.
+// Entities are identified by both their ID and their type.
+type EntityStore interface {
+	// Entity returns the entity's details and review statistics, or
+	// ErrNotFound if it has never been reviewed or put in a list. Like
+	// EntityReviews and EntityLists, it only considers public posts, and
+	// leaves out private accounts that viewerID doesn't follow.
+	Entity(ctx context.Context, entityID string, entityType int, viewerID string) (Entity, error)
+	// EntityReviews returns up to limit reviews of the entity, those by
+	// users that viewerID follows first and then newest first.
+	EntityReviews(ctx context.Context, entityID string, entityType int, viewerID string, limit int) ([]Post, error)
+	// EntityLists returns up to limit of the lists that include the
+	// entity, newest first, without their elements.
+	EntityLists(ctx context.Context, entityID string, entityType int, viewerID string, limit int) ([]Post, error)
+}
+
+// NotificationStore holds the notifications sent to each user.
+type NotificationStore interface {
+	// Notify adds the notification, or merges it into an earlier one and
+	// marks that unread again if it collapses.
+	Notify(ctx context.Context, notification Notification) error
+	// Notifications returns a page of the user's notifications, most
+	// recently updated first.
+	Notifications(ctx context.Context, userID string, page Page) ([]NotificationBag, error)
+	UnreadNotificationCount(ctx context.Context, userID string) (int, error)
+	// MarkNotificationsRead marks the given notifications as read, or all
+	// of the user's notifications if ids is empty. IDs of notifications
+	// that belong to other users are ignored.
+	MarkNotificationsRead(ctx context.Context, userID string, ids []int) error
+}
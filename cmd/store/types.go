@@ -0,0 +1,190 @@
+package store
+
+import "time"
+
+const (
+	ReviewType int = iota
+	ListType
+)
+
+type User struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	ImageSource string      `json:"imageSrc"`
+	Colour      string      `json:"colour"`
+	Followers   int         `json:"followers"`
+	Following   int         `json:"following"`
+	Reviews     int         `json:"reviews"`
+	Lists       int         `json:"lists"`
+	IsPrivate   bool        `json:"isPrivate"`
+	IsFollowing bool        `json:"isFollowing"`
+	IsRequested bool        `json:"isRequested"`
+	MusicNotes  []MusicNote `json:"musicNotes"`
+	CreatedOn   time.Time   `json:"createdOn"`
+}
+
+type UserCondensed struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ImageSource string `json:"imageSrc"`
+}
+
+type MusicNote struct {
+	EntityID    string `json:"entityId"`
+	Prompt      string `json:"prompt"`
+	ImageSource string `json:"imageSrc"`
+	Title       string `json:"title"`
+	Subtitle    string `json:"subtitle"`
+}
+
+type Review struct {
+	UserID      string    `json:"userId"`
+	EntityID    string    `json:"entityId"`
+	Type        int       `json:"type"`
+	Title       string    `json:"title"`
+	Subtitle    string    `json:"subtitle"`
+	Colour      string    `json:"colour"`
+	ImageSource string    `json:"imageSrc"`
+	Score       int       `json:"score"`
+	Body        string    `json:"body"`
+	Visibility  string    `json:"visibility"`
+	CreatedOn   time.Time `json:"createdOn"`
+}
+
+// ListElement is an entry in a list. AddedBy is the list's owner or the
+// collaborator that added it, and Note is their optional explanation of
+// why it's there.
+type ListElement struct {
+	EntityID string        `json:"entityId"`
+	Name     string        `json:"name"`
+	ImageSrc string        `json:"src"`
+	Note     string        `json:"note,omitempty"`
+	AddedBy  UserCondensed `json:"addedBy"`
+}
+
+type List struct {
+	UserID       string        `json:"userId"`
+	Type         int           `json:"type"`
+	Title        string        `json:"title"`
+	Colour       string        `json:"colour"`
+	Ranked       bool          `json:"ranked"`
+	ListElements []ListElement `json:"listElements"`
+	ForkedFrom   string        `json:"forkedFrom,omitempty"`
+	Visibility   string        `json:"visibility"`
+	CreatedOn    time.Time     `json:"createdOn"`
+}
+
+type ListBag struct {
+	ID           string        `json:"id"`
+	Type         int           `json:"type"`
+	Title        string        `json:"title"`
+	Colour       string        `json:"colour"`
+	Ranked       bool          `json:"ranked"`
+	ListElements []ListElement `json:"listElements"`
+	UpdatedOn    *time.Time    `json:"updatedOn,omitempty"`
+	ForkedFrom   string        `json:"forkedFrom,omitempty"`
+	NumForks     int           `json:"numForks"`
+	Visibility   string        `json:"visibility"`
+}
+
+// ListUpdate is the new title and colour of a list that its owner has
+// edited. An empty Visibility leaves the list's visibility as it is.
+type ListUpdate struct {
+	Title      string
+	Colour     string
+	Visibility string
+	UpdatedOn  time.Time
+}
+
+type ReviewBag struct {
+	ID          int        `json:"id"`
+	EntityID    string     `json:"entityId"`
+	Type        int        `json:"type"`
+	Title       string     `json:"title"`
+	Subtitle    string     `json:"subtitle"`
+	Colour      string     `json:"colour"`
+	ImageSource string     `json:"imageSrc"`
+	Score       int        `json:"score"`
+	Body        string     `json:"body"`
+	IsEdited    bool       `json:"isEdited"`
+	EditedOn    *time.Time `json:"editedOn,omitempty"`
+	Visibility  string     `json:"visibility"`
+}
+
+// ReviewEdit is the new contents of a review that its author has edited.
+// An empty Visibility leaves the review's visibility as it is.
+type ReviewEdit struct {
+	Title      string
+	Subtitle   string
+	Score      int
+	Body       string
+	Visibility string
+	EditedOn   time.Time
+}
+
+// ChangesContents reports whether the edit changes anything other than the
+// visibility of a review whose current contents are in previous. Only
+// those edits are kept as revisions and mark the review as edited.
+func (e ReviewEdit) ChangesContents(previous ReviewRevision) bool {
+	return e.Title != previous.Title || e.Subtitle != previous.Subtitle || e.Score != previous.Score || e.Body != previous.Body
+}
+
+// ReviewRevision is an earlier version of a review. ReplacedOn is when the
+// author edited it.
+type ReviewRevision struct {
+	Title      string    `json:"title"`
+	Subtitle   string    `json:"subtitle"`
+	Score      int       `json:"score"`
+	Body       string    `json:"body"`
+	ReplacedOn time.Time `json:"replacedOn"`
+}
+
+// Entity is an album, track or artist as seen through the reviews of it.
+// Its title, subtitle and image come from its latest review, or from a
+// list that includes it if nobody has reviewed it. ScoreDistribution maps
+// each score to the number of reviews that gave it.
+type Entity struct {
+	EntityID          string      `json:"entityId"`
+	Type              int         `json:"type"`
+	Title             string      `json:"title"`
+	Subtitle          string      `json:"subtitle"`
+	ImageSource       string      `json:"imageSrc"`
+	NumReviews        int         `json:"numReviews"`
+	AverageScore      float64     `json:"averageScore"`
+	ScoreDistribution map[int]int `json:"scoreDistribution"`
+}
+
+// Comment is a new comment on a review or list. ParentID is the comment
+// being replied to, or 0 for a top-level comment.
+type Comment struct {
+	UserID    string
+	ParentID  int
+	Body      string
+	CreatedOn time.Time
+}
+
+type CommentBag struct {
+	ID        int           `json:"id"`
+	ParentID  int           `json:"parentId,omitempty"`
+	Author    UserCondensed `json:"author"`
+	Body      string        `json:"body"`
+	CreatedOn time.Time     `json:"createdOn"`
+	Replies   []CommentBag  `json:"replies,omitempty"`
+}
+
+// LikeSummary is the number of likes on a review or list and whether the
+// viewer is one of the users that liked it.
+type LikeSummary struct {
+	Count   int
+	IsLiked bool
+}
+
+// Post is a single review or list in a feed. Type says which of Review
+// and List is populated.
+type Post struct {
+	Type      int
+	Author    UserCondensed
+	Timestamp time.Time
+	Review    ReviewBag
+	List      ListBag
+}
@@ -0,0 +1,23 @@
+package store
+
+// Visibilities of a review or list, which say who can see it. Private
+// posts are drafts that only their author can see until they're
+// published.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityPrivate   = "private"
+)
+
+// ValidVisibility reports whether v is one of the visibilities.
+func ValidVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityFollowers || v == VisibilityPrivate
+}
+
+// Visibility returns the visibility of the review or list in the post.
+func (p Post) Visibility() string {
+	if p.Type == ReviewType {
+		return p.Review.Visibility
+	}
+	return p.List.Visibility
+}
@@ -8,10 +8,10 @@ require (
 	github.com/gorilla/mux v1.8.0
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	gopkg.in/go-jose/go-jose.v2 v2.6.1
 )
 
 require (
 	golang.org/x/crypto v0.4.0 // indirect
 	golang.org/x/sync v0.5.0 // indirect
-	gopkg.in/go-jose/go-jose.v2 v2.6.1 // indirect
 )